@@ -0,0 +1,123 @@
+package mp4parser
+
+import (
+	"errors"
+	"io"
+)
+
+//errNotADTS is returned when converting track of codec other than AAC , or of config ADTS can't carry , to ADTS
+var errNotADTS = errors.New("mp4parser: track is not AAC of ADTS compatible config")
+
+//adtsHeaderSize size of ADTS header without CRC
+const adtsHeaderSize = 7
+
+//adtsMaxFrameLength max frame_length of ADTS header , including the header
+const adtsMaxFrameLength = 1<<13 - 1
+
+//ADTSWriter wraps raw AAC samples in ADTS headers , as ISO/IEC 13818-7 ,
+//	it reuses its buffer and is not safe for concurrent use
+type ADTSWriter struct {
+	w      io.Writer
+	header [adtsHeaderSize]byte //frame_length is set for each sample
+	buf    []byte
+}
+
+//NewADTSWriter return ADTSWriter writing to w , with AudioSpecificConfig in esds of the first sample entry of t ,
+//	return an error if t is not AAC , or profile , sample rate or channels can't be signalled in ADTS
+/**
+*syncword					12 bits	//0xFFF
+*ID							1 bit	//0 for MPEG-4 , 1 for MPEG-2
+*layer						2 bits	//0
+*protection_absent			1 bit	//1 , no CRC
+*profile					2 bits	//audio object type - 1
+*sampling_frequency_index	4 bits
+*private_bit				1 bit
+*channel_configuration		3 bits
+*original_copy				1 bit
+*home						1 bit
+*copyright_id_bit			1 bit
+*copyright_id_start			1 bit
+*frame_length				13 bits	//including header
+*buffer_fullness			11 bits	//0x7FF for variable bitrate
+*number_of_raw_data_blocks	2 bits	//number of AAC frames - 1
+ */
+func (t *Track) NewADTSWriter(w io.Writer) (*ADTSWriter, error) {
+	if t.audio == nil || t.audio.ES == nil || t.audio.ES.AudioConfig == nil {
+		return nil, errNotADTS
+	}
+	c := t.audio.ES.AudioConfig
+	if c.BaseObjectType < 1 || c.BaseObjectType > 4 || c.ChannelConfig > 7 { //HE-AAC is signalled implicitly by its core
+		return nil, errNotADTS
+	}
+
+	index := c.SampleRateIndex
+	if int(index) >= len(mpeg4SampleRates) {
+		index = 0xf
+		for i, rate := range mpeg4SampleRates {
+			if rate == c.SampleRate {
+				index = uint8(i)
+			}
+		}
+		if index == 0xf {
+			return nil, errNotADTS
+		}
+	}
+
+	id := byte(0)
+	if t.audio.ES.ObjectTypeIndication != objectTypeMPEG4Audio {
+		id = 1
+	}
+
+	a := &ADTSWriter{w: w}
+	a.header[0] = 0xff
+	a.header[1] = 0xf0 | id<<3 | 1
+	a.header[2] = (c.BaseObjectType-1)<<6 | index<<2 | c.ChannelConfig>>2
+	a.header[3] = c.ChannelConfig & 3 << 6
+	a.header[5] = 0x1f //buffer_fullness 0x7FF
+	a.header[6] = 0xfc
+	return a, nil
+}
+
+//WriteSample writes data of a sample , a raw AAC frame , with ADTS header ,
+//	return ErrInvalidData if the frame is too long for ADTS , or an error of writing
+func (a *ADTSWriter) WriteSample(data []byte) error {
+	length := adtsHeaderSize + len(data)
+	if length > adtsMaxFrameLength {
+		return ErrInvalidData
+	}
+
+	a.header[3] = a.header[3]&0xfc | byte(length>>11)
+	a.header[4] = byte(length >> 3)
+	a.header[5] = byte(length)<<5 | a.header[5]&0x1f
+
+	a.buf = append(a.buf[:0], a.header[:]...)
+	a.buf = append(a.buf, data...)
+	_, err := a.w.Write(a.buf)
+	return err
+}
+
+//WriteADTS writes all samples of t to w as ADTS stream , e.g. .aac file ,
+//	return an error if t is not AAC , or any of reading and writing
+func (t *Track) WriteADTS(w io.Writer) error {
+	a, err := t.NewADTSWriter(w)
+	if err != nil {
+		return err
+	}
+	r, err := t.NewSampleReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		_, data, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = a.WriteSample(data); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteADTS(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := info.Tracks()[1]
+
+	out := new(bytes.Buffer)
+	if err := audio.WriteADTS(out); err != nil {
+		t.Fatal(err)
+	}
+
+	samples, _ := audio.Samples()
+	data := out.Bytes()
+	for _, s := range samples {
+		//MPEG-4 , no CRC , AAC LC , 48000 Hz , mono
+		if len(data) < adtsHeaderSize || !bytes.Equal(data[:3], []byte{0xff, 0xf1, 0x4c}) || data[3]>>6 != 1 {
+			t.Fatalf("sample %d,got header %x", s.Number, data[:adtsHeaderSize])
+		}
+		length := int(data[3]&3)<<11 | int(data[4])<<3 | int(data[5]>>5)
+		if length != adtsHeaderSize+int(s.Size) || data[5]&0x1f != 0x1f || data[6] != 0xfc {
+			t.Fatalf("sample %d,want frame length %d , got header %x", s.Number, adtsHeaderSize+s.Size, data[:adtsHeaderSize])
+		}
+		data = data[length:]
+	}
+	if len(data) != 0 {
+		t.Errorf("got %d bytes left", len(data))
+	}
+
+	if err := info.Tracks()[0].WriteADTS(out); err != errNotADTS {
+		t.Errorf("video track,want %v , got %v", errNotADTS, err)
+	}
+}
+
+func TestADTSWriter(t *testing.T) {
+	tests := [...]struct {
+		name   string
+		oti    uint8
+		config AudioSpecificConfig
+		header []byte
+		err    error
+	}{
+		{"MPEG-2 AAC LC", objectTypeMPEG2AACLC, AudioSpecificConfig{BaseObjectType: 2, SampleRateIndex: 4, ChannelConfig: 2},
+			[]byte{0xff, 0xf9, 0x50, 0x80}, nil},
+		{"HE-AAC with explicit sample rate", objectTypeMPEG4Audio,
+			AudioSpecificConfig{ObjectType: 5, BaseObjectType: 2, SampleRateIndex: 15, SampleRate: 22050, ChannelConfig: 6},
+			[]byte{0xff, 0xf1, 0x5d, 0x80}, nil},
+		{"unusual sample rate", objectTypeMPEG4Audio, AudioSpecificConfig{BaseObjectType: 2, SampleRateIndex: 15, SampleRate: 1000}, nil, errNotADTS},
+		{"object type beyond ADTS profiles", objectTypeMPEG4Audio, AudioSpecificConfig{BaseObjectType: 23, SampleRateIndex: 3}, nil, errNotADTS},
+	}
+	for _, test := range tests {
+		config := test.config
+		track := &Track{audio: &AudioInfo{ES: &ESDescriptor{ObjectTypeIndication: test.oti, AudioConfig: &config}}}
+		out := new(bytes.Buffer)
+		a, err := track.NewADTSWriter(out)
+		if err != test.err {
+			t.Errorf("%s: want %v , got %v", test.name, test.err, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if err := a.WriteSample(make([]byte, 1000)); err != nil {
+			t.Fatal(err)
+		}
+		want := append(test.header, 0x7d, 0xff, 0xfc) //frame length 1007
+		if !bytes.Equal(out.Bytes()[:adtsHeaderSize], want) || out.Len() != 1007 {
+			t.Errorf("%s: want header %x , got %x", test.name, want, out.Bytes()[:adtsHeaderSize])
+		}
+		if err := a.WriteSample(make([]byte, adtsMaxFrameLength)); err != ErrInvalidData {
+			t.Errorf("%s: too long frame,want %v , got %v", test.name, ErrInvalidData, err)
+		}
+	}
+}
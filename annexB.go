@@ -0,0 +1,144 @@
+package mp4parser
+
+import (
+	"errors"
+	"io"
+)
+
+//errNotAnnexB is returned when converting track of codec other than H.264 and HEVC to Annex B
+var errNotAnnexB = errors.New("mp4parser: track is not H.264 or HEVC")
+
+//NAL unit types inserted or checked in Annex B stream
+const (
+	avcNALSPS        = 7
+	hevcNALPrefixSEI = 39
+)
+
+//annexBStartCode precedes each NAL unit in Annex B byte stream
+var annexBStartCode = []byte{0, 0, 0, 1}
+
+//AnnexBWriter converts length prefixed samples of H.264 or HEVC to Annex B byte stream , as ITU-T H.264 Annex B ,
+//	it reuses its buffer and is not safe for concurrent use
+type AnnexBWriter struct {
+	w          io.Writer
+	hevc       bool
+	lengthSize int
+	paramSets  [][]byte //inserted before sync samples
+	buf        []byte
+}
+
+//NewAnnexBWriter return AnnexBWriter writing to w , with NAL length size and parameter sets of the first sample entry of t ,
+//	return an error if t is not H.264 or HEVC
+func (t *Track) NewAnnexBWriter(w io.Writer) (*AnnexBWriter, error) {
+	a := &AnnexBWriter{w: w}
+	switch {
+	case t.video != nil && t.video.AVC != nil:
+		c := t.video.AVC
+		a.lengthSize = int(c.NALLengthSize)
+		a.paramSets = append(a.paramSets, c.SPS...)
+		a.paramSets = append(a.paramSets, c.SPSExt...)
+		a.paramSets = append(a.paramSets, c.PPS...)
+	case t.video != nil && t.video.HEVC != nil:
+		c := t.video.HEVC
+		a.hevc = true
+		a.lengthSize = int(c.NALLengthSize)
+		for _, nalType := range [...]uint8{hevcNALVPS, hevcNALSPS, hevcNALPPS, hevcNALPrefixSEI} {
+			a.paramSets = append(a.paramSets, c.NALUnits(nalType)...)
+		}
+	default:
+		return nil, errNotAnnexB
+	}
+
+	return a, nil
+}
+
+//WriteSample converts data of a sample and writes it , parameter sets are inserted if sync is true
+//	and the sample does not carry a SPS , return ErrInvalidData if NAL unit lengths overrun data ,
+//	or an error of writing
+func (a *AnnexBWriter) WriteSample(data []byte, sync bool) error {
+	a.buf = a.buf[:0]
+	if sync {
+		hasSPS, err := a.hasSPS(data)
+		if err != nil {
+			return err
+		}
+		if !hasSPS {
+			for _, nal := range a.paramSets {
+				a.buf = append(a.buf, annexBStartCode...)
+				a.buf = append(a.buf, nal...)
+			}
+		}
+	}
+
+	for len(data) > 0 {
+		nal, rest, err := a.nextNAL(data)
+		if err != nil {
+			return err
+		}
+		a.buf = append(a.buf, annexBStartCode...)
+		a.buf = append(a.buf, nal...)
+		data = rest
+	}
+
+	_, err := a.w.Write(a.buf)
+	return err
+}
+
+//nextNAL return the first NAL unit in data and data left
+func (a *AnnexBWriter) nextNAL(data []byte) (nal, rest []byte, err error) {
+	if len(data) < a.lengthSize {
+		return nil, nil, ErrInvalidData
+	}
+	length := 0
+	for _, b := range data[:a.lengthSize] {
+		length = length<<8 | int(b)
+	}
+	data = data[a.lengthSize:]
+	if length > len(data) {
+		return nil, nil, ErrInvalidData
+	}
+	return data[:length], data[length:], nil
+}
+
+//hasSPS reports whether data of sample contains a SPS NAL unit , e.g. in avc3 or hev1 samples
+func (a *AnnexBWriter) hasSPS(data []byte) (bool, error) {
+	for len(data) > 0 {
+		nal, rest, err := a.nextNAL(data)
+		if err != nil {
+			return false, err
+		}
+		if len(nal) > 0 {
+			if a.hevc && nal[0]>>1&0x3f == hevcNALSPS || !a.hevc && nal[0]&0x1f == avcNALSPS {
+				return true, nil
+			}
+		}
+		data = rest
+	}
+	return false, nil
+}
+
+//WriteAnnexB writes all samples of t to w as Annex B byte stream , e.g. raw .h264 or .h265 file ,
+//	return an error if t is not H.264 or HEVC , or any of reading and writing
+func (t *Track) WriteAnnexB(w io.Writer) error {
+	a, err := t.NewAnnexBWriter(w)
+	if err != nil {
+		return err
+	}
+	r, err := t.NewSampleReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		s, data, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err = a.WriteSample(data, s.Sync); err != nil {
+			return err
+		}
+	}
+}
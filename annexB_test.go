@@ -0,0 +1,82 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteAnnexB(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := info.Tracks()[0]
+	c := video.Video().AVC
+
+	out := new(bytes.Buffer)
+	if err := video.WriteAnnexB(out); err != nil {
+		t.Fatal(err)
+	}
+
+	//4 bytes NAL lengths are replaced by start codes , and parameter sets are inserted before the only key frame
+	samples, _ := video.Samples()
+	want := 8 + len(c.SPS[0]) + len(c.PPS[0])
+	for _, s := range samples {
+		want += int(s.Size)
+	}
+	head := bytes.Join([][]byte{annexBStartCode, c.SPS[0], annexBStartCode, c.PPS[0], annexBStartCode}, nil)
+	if out.Len() != want || !bytes.HasPrefix(out.Bytes(), head) {
+		t.Errorf("want %d bytes beginning with %x , got %d bytes beginning with %x", want, head, out.Len(), out.Bytes()[:len(head)])
+	}
+
+	if err := info.Tracks()[1].WriteAnnexB(out); err != errNotAnnexB {
+		t.Errorf("audio track,want %v , got %v", errNotAnnexB, err)
+	}
+}
+
+func TestAnnexBWriter(t *testing.T) {
+	vps, sps, pps := []byte{0x40, 0x01, 0xaa}, []byte{0x42, 0x01, 0xbb}, []byte{0x44, 0x01, 0xcc}
+	track := &Track{video: &VideoInfo{HEVC: &HEVCConfig{
+		NALLengthSize: 2,
+		NALArrays: []HEVCNALArray{
+			{NALUnitType: hevcNALSPS, NALUnits: [][]byte{sps}},
+			{NALUnitType: hevcNALVPS, NALUnits: [][]byte{vps}},
+			{NALUnitType: hevcNALPPS, NALUnits: [][]byte{pps}},
+		},
+	}}}
+	out := new(bytes.Buffer)
+	a, err := track.NewAnnexBWriter(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slice := []byte{0x26, 0x01, 0xdd, 0xee} //IDR_W_RADL
+	tests := [...]struct {
+		name string
+		data []byte
+		sync bool
+		want [][]byte
+	}{
+		{"key frame", be(uint16(4), slice), true, [][]byte{vps, sps, pps, slice}},
+		{"non key frame", be(uint16(2), []byte{0x02, 0x01}, uint16(0)), false, [][]byte{{0x02, 0x01}, {}}},
+		{"key frame with in-band parameter sets", be(uint16(3), sps, uint16(4), slice), true, [][]byte{sps, slice}},
+	}
+	for _, test := range tests {
+		out.Reset()
+		if err := a.WriteSample(test.data, test.sync); err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		want := append([]byte(nil), annexBStartCode...)
+		want = append(want, bytes.Join(test.want, annexBStartCode)...)
+		if !bytes.Equal(out.Bytes(), want) {
+			t.Errorf("%s: want %x , got %x", test.name, want, out.Bytes())
+		}
+	}
+
+	for _, data := range [...][]byte{be(uint16(5), slice), {0}} {
+		if err := a.WriteSample(data, false); err != ErrInvalidData {
+			t.Errorf("%x: want %v , got %v", data, ErrInvalidData, err)
+		}
+	}
+}
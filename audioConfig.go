@@ -0,0 +1,378 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+)
+
+//OpusConfig Opus specific box , dOps
+type OpusConfig struct {
+	Version              uint8
+	OutputChannelCount   uint8
+	PreSkip              uint16 //samples at 48kHz to discard from the start
+	InputSampleRate      uint32 //sample rate of the original input , informational
+	OutputGain           int16  //[8.8] dB
+	ChannelMappingFamily uint8
+	StreamCount          uint8 //following are present if ChannelMappingFamily != 0
+	CoupledCount         uint8
+	ChannelMapping       []uint8
+}
+
+//parseOpusConfig parses data of dOps box
+/**
+*version				1
+*output_channel_count	1
+*pre_skip				2
+*input_sample_rate		4
+*output_gain			2
+*channel_mapping_family	1
+*stream_count			1	//if channel_mapping_family != 0
+*coupled_count			1
+*channel_mapping		output_channel_count
+ */
+func parseOpusConfig(data []byte) (interface{}, error) {
+	if len(data) < 11 {
+		return nil, ErrTruncated
+	}
+
+	c := &OpusConfig{
+		Version:              data[0],
+		OutputChannelCount:   data[1],
+		PreSkip:              binary.BigEndian.Uint16(data[2:4]),
+		InputSampleRate:      binary.BigEndian.Uint32(data[4:8]),
+		OutputGain:           int16(binary.BigEndian.Uint16(data[8:10])),
+		ChannelMappingFamily: data[10],
+	}
+	if c.Version != 0 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if c.ChannelMappingFamily != 0 {
+		if len(data) < 13+int(c.OutputChannelCount) {
+			return nil, ErrTruncated
+		}
+		c.StreamCount = data[11]
+		c.CoupledCount = data[12]
+		c.ChannelMapping = append([]uint8(nil), data[13:13+int(c.OutputChannelCount)]...)
+	}
+
+	return c, nil
+}
+
+//ac3SampleRates are sample rates indexed by fscod
+var ac3SampleRates = [...]uint32{48000, 44100, 32000}
+
+//ac3Channels are number of full bandwidth channels indexed by acmod
+var ac3Channels = [...]uint16{2, 1, 2, 3, 3, 4, 4, 5}
+
+//AC3Config AC-3 specific box , dac3
+type AC3Config struct {
+	SampleRateCode uint8 //fscod
+	BSID           uint8
+	BSMod          uint8
+	ACMod          uint8 //audio coding mode , arrangement of channels
+	LFEOn          bool
+	BitRateCode    uint8
+}
+
+//SampleRate return sample rate in Hz , 0 if reserved
+func (c *AC3Config) SampleRate() uint32 {
+	if int(c.SampleRateCode) < len(ac3SampleRates) {
+		return ac3SampleRates[c.SampleRateCode]
+	}
+	return 0
+}
+
+//ChannelCount return number of channels including LFE
+func (c *AC3Config) ChannelCount() uint16 {
+	n := ac3Channels[c.ACMod&7]
+	if c.LFEOn {
+		n++
+	}
+	return n
+}
+
+//parseAC3Config parses data of dac3 box
+/**
+*fscod			2 bits
+*bsid			5 bits
+*bsmod			3 bits
+*acmod			3 bits
+*lfeon			1 bit
+*bit_rate_code	5 bits
+*_reserved		5 bits
+ */
+func parseAC3Config(data []byte) (interface{}, error) {
+	r := newBitReader(data)
+	c := &AC3Config{
+		SampleRateCode: uint8(r.readBits(2)),
+		BSID:           uint8(r.readBits(5)),
+		BSMod:          uint8(r.readBits(3)),
+		ACMod:          uint8(r.readBits(3)),
+		LFEOn:          r.readFlag(),
+		BitRateCode:    uint8(r.readBits(5)),
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return c, nil
+}
+
+//eac3ChanLocChannels are number of channels of each bit in chan_loc , from the most significant one
+//	Lc/Rc , Lrs/Rrs , Cs , Ts , Lsd/Rsd , Lw/Rw , Lvh/Rvh , Cvh , LFE2
+var eac3ChanLocChannels = [...]uint16{2, 2, 1, 1, 2, 2, 2, 1, 1}
+
+//EAC3Config Enhanced AC-3 specific box , dec3
+type EAC3Config struct {
+	DataRate   uint16 //kbit/s
+	Substreams []EAC3Substream
+}
+
+//EAC3Substream independent substream in EAC3Config
+type EAC3Substream struct {
+	SampleRateCode uint8 //fscod
+	BSID           uint8
+	ASVC           bool
+	BSMod          uint8
+	ACMod          uint8
+	LFEOn          bool
+	NumDepSub      uint8  //number of dependent substreams
+	ChanLoc        uint16 //channel locations of dependent substreams , 9 bits
+}
+
+//SampleRate return sample rate in Hz of the first independent substream , 0 if reserved
+func (c *EAC3Config) SampleRate() uint32 {
+	if len(c.Substreams) > 0 && int(c.Substreams[0].SampleRateCode) < len(ac3SampleRates) {
+		return ac3SampleRates[c.Substreams[0].SampleRateCode]
+	}
+	return 0
+}
+
+//ChannelCount return number of channels of the first independent substream and its dependent ones
+func (c *EAC3Config) ChannelCount() uint16 {
+	if len(c.Substreams) == 0 {
+		return 0
+	}
+
+	s := c.Substreams[0]
+	n := ac3Channels[s.ACMod&7]
+	if s.LFEOn {
+		n++
+	}
+	if s.NumDepSub > 0 {
+		for i, channels := range eac3ChanLocChannels {
+			if s.ChanLoc>>(8-uint(i))&1 == 1 {
+				n += channels
+			}
+		}
+	}
+	return n
+}
+
+//parseEAC3Config parses data of dec3 box
+/**
+*data_rate				13 bits
+*num_ind_sub			3 bits	//number of independent substreams - 1
+*for each independent substream
+*	fscod				2 bits
+*	bsid				5 bits
+*	_reserved			1 bit
+*	asvc				1 bit
+*	bsmod				3 bits
+*	acmod				3 bits
+*	lfeon				1 bit
+*	_reserved			3 bits
+*	num_dep_sub			4 bits
+*	chan_loc			9 bits	//if num_dep_sub > 0 , otherwise 1 bit reserved
+ */
+func parseEAC3Config(data []byte) (interface{}, error) {
+	r := newBitReader(data)
+	c := &EAC3Config{
+		DataRate: uint16(r.readBits(13)),
+	}
+
+	n := int(r.readBits(3)) + 1
+	for i := 0; i < n && r.err == nil; i++ {
+		s := EAC3Substream{
+			SampleRateCode: uint8(r.readBits(2)),
+			BSID:           uint8(r.readBits(5)),
+		}
+		r.skip(1)
+		s.ASVC = r.readFlag()
+		s.BSMod = uint8(r.readBits(3))
+		s.ACMod = uint8(r.readBits(3))
+		s.LFEOn = r.readFlag()
+		r.skip(3)
+		s.NumDepSub = uint8(r.readBits(4))
+		if s.NumDepSub > 0 {
+			s.ChanLoc = uint16(r.readBits(9))
+		} else {
+			r.skip(1)
+		}
+		c.Substreams = append(c.Substreams, s)
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return c, nil
+}
+
+//FLACStreamInfo STREAMINFO metadata block in FLAC specific box , dfLa
+type FLACStreamInfo struct {
+	MinBlockSize  uint16
+	MaxBlockSize  uint16
+	MinFrameSize  uint32
+	MaxFrameSize  uint32
+	SampleRate    uint32
+	ChannelCount  uint8
+	BitsPerSample uint8
+	TotalSamples  uint64
+	MD5           [16]byte
+}
+
+//parseFLACConfig parses data of dfLa box , a full box of metadata blocks
+/**
+*version				1
+*flags					3
+*for each metadata block
+*	last_metadata_block	1 bit
+*	block_type			7 bits	//0 for STREAMINFO
+*	length				24 bits
+*	block data			length
+*STREAMINFO
+*	min_block_size		16 bits
+*	max_block_size		16 bits
+*	min_frame_size		24 bits
+*	max_frame_size		24 bits
+*	sample_rate			20 bits
+*	channels			3 bits	//channels - 1
+*	bits_per_sample		5 bits	//bits_per_sample - 1
+*	total_samples		36 bits
+*	md5					128 bits
+ */
+func parseFLACConfig(data []byte) (interface{}, error) {
+	if len(data) < 4 {
+		return nil, ErrTruncated
+	}
+	if data[0] != 0 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	for blocks := data[4:]; len(blocks) >= 4; {
+		blockType := blocks[0] & 0x7f
+		length := int(blocks[1])<<16 | int(blocks[2])<<8 | int(blocks[3])
+		if len(blocks) < 4+length {
+			return nil, ErrTruncated
+		}
+
+		if blockType == 0 { //STREAMINFO
+			r := newBitReader(blocks[4 : 4+length])
+			c := &FLACStreamInfo{
+				MinBlockSize:  uint16(r.readBits(16)),
+				MaxBlockSize:  uint16(r.readBits(16)),
+				MinFrameSize:  uint32(r.readBits(24)),
+				MaxFrameSize:  uint32(r.readBits(24)),
+				SampleRate:    uint32(r.readBits(20)),
+				ChannelCount:  uint8(r.readBits(3)) + 1,
+				BitsPerSample: uint8(r.readBits(5)) + 1,
+				TotalSamples:  r.readBits(36),
+			}
+			for i := range c.MD5 {
+				c.MD5[i] = uint8(r.readBits(8))
+			}
+			if r.err != nil {
+				return nil, r.err
+			}
+			return c, nil
+		}
+
+		if blocks[0]&0x80 != 0 { //last block
+			break
+		}
+		blocks = blocks[4+length:]
+	}
+
+	return nil, ErrMissingBox
+}
+
+//ALACConfig ALAC specific config , in alac box inside alac sample entry
+type ALACConfig struct {
+	FrameLength       uint32
+	CompatibleVersion uint8
+	BitDepth          uint8
+	PB                uint8
+	MB                uint8
+	KB                uint8
+	NumChannels       uint8
+	MaxRun            uint16
+	MaxFrameBytes     uint32
+	AvgBitRate        uint32
+	SampleRate        uint32
+}
+
+//parseALACConfig parses data of alac box , a full box of ALACSpecificConfig
+/**
+*version				1
+*flags					3
+*frame_length			4
+*compatible_version		1
+*bit_depth				1
+*pb						1
+*mb						1
+*kb						1
+*num_channels			1
+*max_run				2
+*max_frame_bytes		4
+*avg_bit_rate			4
+*sample_rate			4
+ */
+func parseALACConfig(data []byte) (interface{}, error) {
+	if len(data) < 28 {
+		return nil, ErrTruncated
+	}
+	if data[0] != 0 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	data = data[4:]
+	return &ALACConfig{
+		FrameLength:       binary.BigEndian.Uint32(data[0:4]),
+		CompatibleVersion: data[4],
+		BitDepth:          data[5],
+		PB:                data[6],
+		MB:                data[7],
+		KB:                data[8],
+		NumChannels:       data[9],
+		MaxRun:            binary.BigEndian.Uint16(data[10:12]),
+		MaxFrameBytes:     binary.BigEndian.Uint32(data[12:16]),
+		AvgBitRate:        binary.BigEndian.Uint32(data[16:20]),
+		SampleRate:        binary.BigEndian.Uint32(data[20:24]),
+	}, nil
+}
+
+//PCMConfig PCM configuration box , pcmC , in ipcm/fpcm sample entry
+type PCMConfig struct {
+	LittleEndian bool
+	SampleSize   uint8 //in bits
+}
+
+//parsePCMConfig parses data of pcmC box
+/**
+*version				1
+*flags					3
+*format_flags			1	//bit 0 is little endian
+*PCM_sample_size		1
+ */
+func parsePCMConfig(data []byte) (interface{}, error) {
+	if len(data) < 6 {
+		return nil, ErrTruncated
+	}
+	if data[0] != 0 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	return &PCMConfig{
+		LittleEndian: data[4]&1 == 1,
+		SampleSize:   data[5],
+	}, nil
+}
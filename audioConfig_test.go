@@ -0,0 +1,49 @@
+package mp4parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAudioConfig(t *testing.T) {
+	tests := [...]struct {
+		name  string
+		parse func([]byte) (interface{}, error)
+		data  []byte
+		want  interface{}
+		err   error
+	}{
+		{"dOps stereo", parseOpusConfig, []byte{0, 2, 0x01, 0x38, 0, 0, 0xbb, 0x80, 0xff, 0x00, 0},
+			&OpusConfig{OutputChannelCount: 2, PreSkip: 312, InputSampleRate: 48000, OutputGain: -256}, nil},
+		{"dOps truncated mapping", parseOpusConfig, []byte{0, 6, 0, 0, 0, 0, 0, 0, 0, 0, 1, 4, 2, 0}, nil, ErrTruncated},
+		{"dOps version", parseOpusConfig, append([]byte{1}, make([]byte, 10)...), nil, ErrUnsupportedVersion},
+		{"dac3 truncated", parseAC3Config, []byte{0x50}, nil, ErrTruncated},
+		{"dac3 stereo", parseAC3Config, []byte{0x10, 0x10, 0x00},
+			&AC3Config{BSID: 8, ACMod: 2}, nil},
+		{"dec3 truncated", parseEAC3Config, []byte{0x0c, 0x00, 0x20}, nil, ErrTruncated},
+		{"dfLa no STREAMINFO", parseFLACConfig, []byte{0, 0, 0, 0, 0x84, 0, 0, 0}, nil, ErrMissingBox},
+		{"dfLa version", parseFLACConfig, []byte{1, 0, 0, 0}, nil, ErrUnsupportedVersion},
+		{"alac truncated", parseALACConfig, make([]byte, 27), nil, ErrTruncated},
+		{"pcmC", parsePCMConfig, []byte{0, 0, 0, 0, 1, 16}, &PCMConfig{LittleEndian: true, SampleSize: 16}, nil},
+	}
+
+	for _, test := range tests {
+		got, err := test.parse(test.data)
+		if err != test.err {
+			t.Errorf("%s: want error %v , got %v", test.name, test.err, err)
+			continue
+		}
+		if test.want != nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: want %+v , got %+v", test.name, test.want, got)
+		}
+	}
+
+	c := &AC3Config{SampleRateCode: 3, ACMod: 1, LFEOn: true}
+	if c.SampleRate() != 0 || c.ChannelCount() != 2 {
+		t.Errorf("reserved fscod , 1/0 with lfe,got %d Hz , %d channels", c.SampleRate(), c.ChannelCount())
+	}
+	e := &EAC3Config{Substreams: []EAC3Substream{{ACMod: 7, LFEOn: true, NumDepSub: 1, ChanLoc: 1 << 7}}}
+	if e.SampleRate() != 48000 || e.ChannelCount() != 8 {
+		t.Errorf("3/2 with lfe and Lrs/Rrs,got %d Hz , %d channels", e.SampleRate(), e.ChannelCount())
+	}
+}
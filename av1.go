@@ -0,0 +1,319 @@
+package mp4parser
+
+//OBU types of AV1
+const (
+	av1OBUSequenceHeader = 1
+)
+
+//AV1Config AV1 codec configuration record , av1C
+type AV1Config struct {
+	Version                  uint8
+	Profile                  uint8 //seq_profile
+	Level                    uint8 //seq_level_idx_0
+	HighTier                 bool  //seq_tier_0
+	BitDepth                 uint8
+	Monochrome               bool
+	ChromaSubsamplingX       bool
+	ChromaSubsamplingY       bool
+	ChromaSamplePosition     uint8
+	InitialPresentationDelay uint8 //in frames , 0 if not present
+	ConfigOBUs               []byte
+
+	SequenceHeader *AV1SequenceHeader //decoded sequence header OBU in ConfigOBUs , nil if there is not any or it fails to decode
+}
+
+//AV1SequenceHeader fields decoded from AV1 sequence header OBU
+type AV1SequenceHeader struct {
+	Profile              uint8
+	StillPicture         bool
+	Level                uint8 //seq_level_idx of the first operating point
+	HighTier             bool
+	MaxWidth             uint32 //max_frame_width_minus_1 + 1
+	MaxHeight            uint32
+	BitDepth             uint8
+	Monochrome           bool
+	ChromaSubsamplingX   bool
+	ChromaSubsamplingY   bool
+	ChromaSamplePosition uint8
+	FilmGrain            bool //film_grain_params_present
+
+	NumUnitsInDisplayTick uint32 //0 if timing info is not present
+	TimeScale             uint32
+
+	VUI //colour description , SAR is not used
+}
+
+//FrameRate return frame rate hinted by timing info , 0 if not present
+func (h *AV1SequenceHeader) FrameRate() float64 {
+	if h.NumUnitsInDisplayTick == 0 {
+		return 0
+	}
+	return float64(h.TimeScale) / float64(h.NumUnitsInDisplayTick)
+}
+
+//parseAV1Config parses data of av1C box
+/**
+*marker								1 bit	//1
+*version							7 bits	//1
+*seq_profile						3 bits
+*seq_level_idx_0					5 bits
+*seq_tier_0							1 bit
+*high_bitdepth						1 bit
+*twelve_bit							1 bit
+*monochrome							1 bit
+*chroma_subsampling_x				1 bit
+*chroma_subsampling_y				1 bit
+*chroma_sample_position				2 bits
+*_reserved							3 bits
+*initial_presentation_delay_present	1 bit
+*initial_presentation_delay_minus_one	4 bits	//reserved if not present
+*configOBUs							rest
+ */
+func parseAV1Config(data []byte) (interface{}, error) {
+	if len(data) < 4 {
+		return nil, ErrTruncated
+	}
+	c := &AV1Config{
+		Version:              data[0] & 0x7f,
+		Profile:              data[1] >> 5,
+		Level:                data[1] & 0x1f,
+		HighTier:             data[2]&0x80 != 0,
+		BitDepth:             8,
+		Monochrome:           data[2]&0x10 != 0,
+		ChromaSubsamplingX:   data[2]&0x08 != 0,
+		ChromaSubsamplingY:   data[2]&0x04 != 0,
+		ChromaSamplePosition: data[2] & 3,
+		ConfigOBUs:           append([]byte(nil), data[4:]...),
+	}
+	if data[0]&0x80 == 0 || c.Version != 1 {
+		return nil, ErrUnsupportedVersion
+	}
+	if highBitDepth, twelveBit := data[2]&0x40 != 0, data[2]&0x20 != 0; highBitDepth {
+		c.BitDepth = 10
+		if c.Profile == 2 && twelveBit {
+			c.BitDepth = 12
+		}
+	}
+	if data[3]&0x10 != 0 {
+		c.InitialPresentationDelay = data[3]&0x0f + 1
+	}
+
+	for obus := c.ConfigOBUs; len(obus) > 0; {
+		obuType, payload, rest, err := readOBU(obus)
+		if err != nil { //configOBUs are kept as they are
+			break
+		}
+		if obuType == av1OBUSequenceHeader {
+			c.SequenceHeader, _ = parseAV1SequenceHeader(payload) //config is still usable without it
+			break
+		}
+		obus = rest
+	}
+
+	return c, nil
+}
+
+//readOBU reads an OBU in data , return its type , payload and data left
+/**
+*obu_forbidden_bit			1 bit
+*obu_type					4 bits
+*obu_extension_flag			1 bit
+*obu_has_size_field			1 bit
+*obu_reserved_1bit			1 bit
+*obu_extension_header		1	//if obu_extension_flag
+*obu_size					leb128	//if obu_has_size_field , otherwise the OBU extends to end of data
+*payload					obu_size
+ */
+func readOBU(data []byte) (obuType uint8, payload, rest []byte, err error) {
+	obuType = data[0] >> 3 & 0xf
+	n := 1
+	if data[0]&0x04 != 0 {
+		n++
+	}
+	if n > len(data) {
+		return 0, nil, nil, ErrTruncated
+	}
+	if data[0]&0x02 == 0 {
+		return obuType, data[n:], nil, nil
+	}
+
+	size := uint64(0)
+	for i := 0; ; i++ {
+		if i == 8 {
+			return 0, nil, nil, ErrInvalidData
+		}
+		if n >= len(data) {
+			return 0, nil, nil, ErrTruncated
+		}
+		size |= uint64(data[n]&0x7f) << (7 * uint(i))
+		n++
+		if data[n-1]&0x80 == 0 {
+			break
+		}
+	}
+	if size > uint64(len(data)-n) {
+		return 0, nil, nil, ErrTruncated
+	}
+	return obuType, data[n : n+int(size)], data[n+int(size):], nil
+}
+
+//parseAV1SequenceHeader decodes payload of sequence header OBU
+/**
+*seq_profile						3 bits
+*still_picture						1 bit
+*reduced_still_picture_header		1 bit
+*seq_level_idx[0]					5 bits	//if reduced_still_picture_header
+*timing_info_present_flag			1 bit	//otherwise
+*timing_info , decoder_model_info	//if timing_info_present_flag
+*initial_display_delay_present_flag	1 bit
+*operating_points_cnt_minus_1		5 bits
+*operating points					//each with seq_level_idx , seq_tier
+*frame_width_bits_minus_1			4 bits
+*frame_height_bits_minus_1			4 bits
+*max_frame_width_minus_1			frame_width_bits_minus_1 + 1 bits
+*max_frame_height_minus_1			frame_height_bits_minus_1 + 1 bits
+*...								//coding tools
+*color_config
+*film_grain_params_present			1 bit
+ */
+func parseAV1SequenceHeader(payload []byte) (*AV1SequenceHeader, error) {
+	r := newBitReader(payload)
+	h := &AV1SequenceHeader{
+		Profile:      uint8(r.readBits(3)),
+		StillPicture: r.readFlag(),
+		VUI:          defaultVUI(),
+	}
+
+	reduced := r.readFlag()
+	decoderModelInfoPresent := false
+	bufferDelayLength := 0
+	if reduced {
+		h.Level = uint8(r.readBits(5))
+	} else {
+		if r.readFlag() { //timing_info_present_flag
+			h.NumUnitsInDisplayTick = uint32(r.readBits(32))
+			h.TimeScale = uint32(r.readBits(32))
+			if r.readFlag() { //equal_picture_interval
+				r.readUE() //num_ticks_per_picture_minus_1 , uvlc
+			}
+			if decoderModelInfoPresent = r.readFlag(); decoderModelInfoPresent {
+				bufferDelayLength = int(r.readBits(5)) + 1
+				r.skip(32 + 5 + 5)
+			}
+		}
+		initialDisplayDelayPresent := r.readFlag()
+		n := int(r.readBits(5)) + 1
+		for i := 0; i < n && r.err == nil; i++ {
+			r.skip(12) //operating_point_idc
+			level := uint8(r.readBits(5))
+			tier := false
+			if level > 7 {
+				tier = r.readFlag()
+			}
+			if i == 0 {
+				h.Level, h.HighTier = level, tier
+			}
+			if decoderModelInfoPresent && r.readFlag() {
+				r.skip(2*bufferDelayLength + 1) //decoder_buffer_delay , encoder_buffer_delay , low_delay_mode_flag
+			}
+			if initialDisplayDelayPresent && r.readFlag() {
+				r.skip(4)
+			}
+		}
+	}
+
+	widthBits := int(r.readBits(4)) + 1
+	heightBits := int(r.readBits(4)) + 1
+	h.MaxWidth = uint32(r.readBits(widthBits)) + 1
+	h.MaxHeight = uint32(r.readBits(heightBits)) + 1
+	if !reduced && r.readFlag() { //frame_id_numbers_present_flag
+		r.skip(4 + 3)
+	}
+	r.skip(3) //use_128x128_superblock , enable_filter_intra , enable_intra_edge_filter
+	if !reduced {
+		r.skip(4) //enable_interintra_compound , enable_masked_compound , enable_warped_motion , enable_dual_filter
+		enableOrderHint := r.readFlag()
+		if enableOrderHint {
+			r.skip(2) //enable_jnt_comp , enable_ref_frame_mvs
+		}
+		forceScreenContentTools := true
+		if !r.readFlag() { //seq_choose_screen_content_tools
+			forceScreenContentTools = r.readFlag()
+		}
+		if forceScreenContentTools && !r.readFlag() { //seq_choose_integer_mv
+			r.skip(1) //seq_force_integer_mv
+		}
+		if enableOrderHint {
+			r.skip(3) //order_hint_bits_minus_1
+		}
+	}
+	r.skip(3) //enable_superres , enable_cdef , enable_restoration
+
+	h.parseColorConfig(r)
+	h.FilmGrain = r.readFlag()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return h, nil
+}
+
+//parseColorConfig decodes color_config of sequence header in r
+/**
+*high_bitdepth						1 bit
+*twelve_bit							1 bit	//if seq_profile == 2 && high_bitdepth
+*mono_chrome						1 bit	//if seq_profile != 1
+*color_description_present_flag		1 bit
+*color_primaries					8 bits	//if color_description_present_flag
+*transfer_characteristics			8 bits
+*matrix_coefficients				8 bits
+*color_range						1 bit	//unless sRGB
+*subsampling_x , subsampling_y		//by seq_profile and bit depth
+*chroma_sample_position				2 bits	//if subsampling_x && subsampling_y
+*separate_uv_delta_q				1 bit	//if !mono_chrome
+ */
+func (h *AV1SequenceHeader) parseColorConfig(r *bitReader) {
+	h.BitDepth = 8
+	if r.readFlag() {
+		h.BitDepth = 10
+		if h.Profile == 2 && r.readFlag() {
+			h.BitDepth = 12
+		}
+	}
+	if h.Profile != 1 {
+		h.Monochrome = r.readFlag()
+	}
+	if r.readFlag() {
+		h.ColourPrimaries = uint8(r.readBits(8))
+		h.TransferCharacteristics = uint8(r.readBits(8))
+		h.MatrixCoefficients = uint8(r.readBits(8))
+	}
+
+	switch {
+	case h.Monochrome:
+		h.VideoFullRange = r.readFlag()
+		h.ChromaSubsamplingX, h.ChromaSubsamplingY = true, true
+		return
+	case h.ColourPrimaries == 1 && h.TransferCharacteristics == 13 && h.MatrixCoefficients == 0: //sRGB
+		h.VideoFullRange = true
+	default:
+		h.VideoFullRange = r.readFlag()
+		switch h.Profile {
+		case 0:
+			h.ChromaSubsamplingX, h.ChromaSubsamplingY = true, true
+		case 1:
+		default:
+			if h.BitDepth == 12 {
+				if h.ChromaSubsamplingX = r.readFlag(); h.ChromaSubsamplingX {
+					h.ChromaSubsamplingY = r.readFlag()
+				}
+			} else {
+				h.ChromaSubsamplingX = true
+			}
+		}
+		if h.ChromaSubsamplingX && h.ChromaSubsamplingY {
+			h.ChromaSamplePosition = uint8(r.readBits(2))
+		}
+	}
+	r.skip(1) //separate_uv_delta_q
+}
@@ -0,0 +1,89 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+//testAV1SequenceHeader is sequence header OBU of main profile , level 4.0 , 1920x1080 4:2:0 10 bits with BT.2020 PQ colour
+var testAV1SequenceHeader = []byte{
+	0x0a, 0x0f, 0x00, 0x00, 0x00, 0x42, 0xed, 0xdf, 0xd0, 0xdc, 0x02, 0x78, 0x50, 0x91, 0x00, 0x90, 0x40,
+}
+
+//mkAV1C return data of av1C box of main profile , level 4.0 , 10 bits with configOBUs
+func mkAV1C(configOBUs ...[]byte) []byte {
+	return append([]byte{0x81, 0x08, 0x4c, 0x00}, bytes.Join(configOBUs, nil)...)
+}
+
+func TestParseAV1Config(t *testing.T) {
+	metadata := []byte{0x2a, 0x02, 0x01, 0x00} //metadata OBU before sequence header
+	got, err := parseAV1Config(mkAV1C(metadata, testAV1SequenceHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := got.(*AV1Config)
+	if c.Profile != 0 || c.Level != 8 || c.HighTier || c.BitDepth != 10 || c.Monochrome ||
+		!c.ChromaSubsamplingX || !c.ChromaSubsamplingY || c.InitialPresentationDelay != 0 {
+		t.Errorf("got %+v", c)
+	}
+
+	want := AV1SequenceHeader{
+		Level: 8, MaxWidth: 1920, MaxHeight: 1080, BitDepth: 10, ChromaSubsamplingX: true, ChromaSubsamplingY: true,
+		VUI: VUI{ColourPrimaries: 9, TransferCharacteristics: 16, MatrixCoefficients: 9},
+	}
+	if c.SequenceHeader == nil || *c.SequenceHeader != want {
+		t.Errorf("want %+v , got %+v", want, c.SequenceHeader)
+	}
+
+	data := mkBox("moov", mkMVHD(1000, 1000),
+		mkTrak(1, "vide", mkSTSD(mkVisualSampleEntry("av01", 1920, 1080, "", mkBox("av1C", mkAV1C(testAV1SequenceHeader))))))
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := info.Tracks()[0].Video()
+	if got := video.CodecString(); got != "av01.0.08M.10" || video.BitDepth() != 10 || !video.HDR() {
+		t.Errorf("want av01.0.08M.10 of 10 bits HDR , got %s , %d , %v", got, video.BitDepth(), video.HDR())
+	}
+
+	if got, err := parseAV1Config(mkAV1C()); err != nil || got.(*AV1Config).SequenceHeader != nil {
+		t.Errorf("without configOBUs,got %+v , %v", got, err)
+	}
+	for _, data := range [...][]byte{
+		{0x81, 0x08, 0x4c},
+		{0x01, 0x08, 0x4c, 0x00},
+	} {
+		if _, err := parseAV1Config(data); err == nil {
+			t.Errorf("parseAV1Config(%x),want error", data)
+		}
+	}
+
+	//config is kept if configOBUs are corrupt
+	for _, data := range [...][]byte{
+		mkAV1C(testAV1SequenceHeader[:10]),
+		mkAV1C([]byte{0x0a, 0x80}),
+		mkAV1C([]byte{0x0a, 0x02, 0x00, 0x00}),
+	} {
+		got, err := parseAV1Config(data)
+		if err != nil {
+			t.Errorf("parseAV1Config(%x),got error %v", data, err)
+			continue
+		}
+		if c := got.(*AV1Config); c.Level != 8 || c.SequenceHeader != nil {
+			t.Errorf("parseAV1Config(%x),want config without SequenceHeader , got %+v", data, c)
+		}
+	}
+	if _, err := parseAV1SequenceHeader([]byte{0x00, 0x00}); err == nil {
+		t.Errorf("parseAV1SequenceHeader(0000),want error")
+	}
+
+	data = mkBox("moov", mkMVHD(1000, 1000),
+		mkTrak(1, "vide", mkSTSD(mkVisualSampleEntry("av01", 1920, 1080, "", mkBox("av1C", mkAV1C([]byte{0x0a, 0x02, 0x00, 0x00}))))))
+	info, err = NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Tracks()[0].CodecString(); got != "av01.0.08M.10" {
+		t.Errorf("with corrupt sequence header,want av01.0.08M.10 , got %s", got)
+	}
+}
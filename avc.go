@@ -0,0 +1,425 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+)
+
+//avcHighProfiles are profile_idc of SPS with chroma format and bit depth
+var avcHighProfiles = map[uint8]bool{
+	100: true, 110: true, 122: true, 244: true, 44: true, 83: true, 86: true,
+	118: true, 128: true, 138: true, 139: true, 134: true, 135: true,
+}
+
+//avcSampleAspectRatios are sample aspect ratios indexed by aspect_ratio_idc of VUI
+var avcSampleAspectRatios = [...][2]uint16{
+	{0, 0}, {1, 1}, {12, 11}, {10, 11}, {16, 11}, {40, 33}, {24, 11}, {20, 11}, {32, 11},
+	{80, 33}, {18, 11}, {15, 11}, {64, 33}, {160, 99}, {4, 3}, {3, 2}, {2, 1},
+}
+
+const avcExtendedSAR = 255 //aspect_ratio_idc of explicit sample aspect ratio
+
+//values of colour description in VUI , see ITU-T H.273
+const (
+	colourUnspecified = 2
+	transferPQ        = 16
+	transferHLG       = 18
+)
+
+//AVCConfig AVC decoder configuration record , avcC
+type AVCConfig struct {
+	Version              uint8
+	Profile              uint8 //AVCProfileIndication
+	ProfileCompatibility uint8 //constraint flags
+	Level                uint8 //AVCLevelIndication
+	NALLengthSize        uint8 //size of NAL unit length in samples , 1 , 2 or 4
+	SPS                  [][]byte
+	PPS                  [][]byte
+
+	ChromaFormat   uint8 //following are present in avcC of high profiles
+	BitDepthLuma   uint8
+	BitDepthChroma uint8
+	SPSExt         [][]byte
+
+	SPSInfo *AVCSPS //decoded first SPS , nil if there is not any or it fails to decode
+}
+
+//AVCSPS fields decoded from AVC sequence parameter set
+type AVCSPS struct {
+	Profile         uint8
+	ConstraintFlags uint8
+	Level           uint8
+	ID              uint32
+	ChromaFormat    uint8 //0 for monochrome , 1 for 4:2:0 , 2 for 4:2:2 , 3 for 4:4:4
+	BitDepthLuma    uint8
+	BitDepthChroma  uint8
+	FrameMbsOnly    bool //false if interlaced coding is allowed
+
+	CodedWidth  uint32 //in macroblocks of 16x16
+	CodedHeight uint32
+	CropLeft    uint32 //in pixels
+	CropRight   uint32
+	CropTop     uint32
+	CropBottom  uint32
+	Width       uint32 //after cropping
+	Height      uint32
+
+	VUI
+	NumUnitsInTick uint32 //0 if timing info is not present
+	TimeScale      uint32
+	FixedFrameRate bool
+}
+
+//VUI video usability information shared by AVC and HEVC SPS , defaults if not present
+type VUI struct {
+	SARWidth                uint16 //sample aspect ratio , 0 if unspecified
+	SARHeight               uint16
+	VideoFullRange          bool
+	ColourPrimaries         uint8 //2 if unspecified
+	TransferCharacteristics uint8
+	MatrixCoefficients      uint8
+}
+
+//HDR reports whether transfer characteristics is PQ (SMPTE ST 2084) or HLG (ARIB STD-B67)
+func (v *VUI) HDR() bool {
+	return v.TransferCharacteristics == transferPQ || v.TransferCharacteristics == transferHLG
+}
+
+//FrameRate return frame rate hinted by timing info , 0 if not present
+func (s *AVCSPS) FrameRate() float64 {
+	if s.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(s.TimeScale) / float64(2*s.NumUnitsInTick)
+}
+
+//parseAVCConfig parses data of avcC box
+/**
+*configurationVersion		1
+*AVCProfileIndication		1
+*profile_compatibility		1
+*AVCLevelIndication			1
+*_reserved					6 bits
+*lengthSizeMinusOne			2 bits
+*_reserved					3 bits
+*numOfSequenceParameterSets	5 bits
+*for each SPS
+*	sequenceParameterSetLength	2
+*	sequenceParameterSetNALUnit	sequenceParameterSetLength
+*numOfPictureParameterSets	1
+*for each PPS
+*	pictureParameterSetLength	2
+*	pictureParameterSetNALUnit	pictureParameterSetLength
+*							//if AVCProfileIndication is one of high profiles , optional
+*_reserved					6 bits
+*chroma_format				2 bits
+*_reserved					5 bits
+*bit_depth_luma_minus8		3 bits
+*_reserved					5 bits
+*bit_depth_chroma_minus8	3 bits
+*numOfSequenceParameterSetExt	1
+*for each SPS extension
+*	sequenceParameterSetExtLength	2
+*	sequenceParameterSetExtNALUnit	sequenceParameterSetExtLength
+ */
+func parseAVCConfig(data []byte) (interface{}, error) {
+	if len(data) < 6 {
+		return nil, ErrTruncated
+	}
+	c := &AVCConfig{
+		Version:              data[0],
+		Profile:              data[1],
+		ProfileCompatibility: data[2],
+		Level:                data[3],
+		NALLengthSize:        data[4]&3 + 1,
+	}
+	if c.Version != 1 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	var err error
+	rest := data[6:]
+	if c.SPS, rest, err = readParameterSets(rest, int(data[5]&0x1f)); err != nil {
+		return nil, err
+	}
+	if len(rest) < 1 {
+		return nil, ErrTruncated
+	}
+	if c.PPS, rest, err = readParameterSets(rest[1:], int(rest[0])); err != nil {
+		return nil, err
+	}
+	if avcHighProfiles[c.Profile] && len(rest) >= 4 {
+		c.ChromaFormat = rest[0] & 3
+		c.BitDepthLuma = rest[1]&7 + 8
+		c.BitDepthChroma = rest[2]&7 + 8
+		if c.SPSExt, _, err = readParameterSets(rest[4:], int(rest[3])); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(c.SPS) > 0 {
+		c.SPSInfo, _ = parseAVCSPS(c.SPS[0]) //config is still usable without it
+	}
+
+	return c, nil
+}
+
+//readParameterSets reads n parameter sets each following its 2 bytes length in data ,
+//	return them and data left
+func readParameterSets(data []byte, n int) ([][]byte, []byte, error) {
+	sets := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		if len(data) < 2 {
+			return nil, nil, ErrTruncated
+		}
+		length := int(binary.BigEndian.Uint16(data[0:2]))
+		if len(data) < 2+length {
+			return nil, nil, ErrTruncated
+		}
+		sets = append(sets, append([]byte(nil), data[2:2+length]...))
+		data = data[2+length:]
+	}
+	return sets, data, nil
+}
+
+//unescapeRBSP return raw byte sequence payload of NAL unit data , removing emulation prevention bytes
+func unescapeRBSP(data []byte) []byte {
+	rbsp := make([]byte, 0, len(data))
+	zeros := 0
+	for _, v := range data {
+		if zeros >= 2 && v == 3 {
+			zeros = 0
+			continue
+		}
+		rbsp = append(rbsp, v)
+		if v == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return rbsp
+}
+
+//parseAVCSPS decodes SPS NAL unit nal , including its 1 byte NAL header , up to timing info of VUI
+/**
+*forbidden_zero_bit , nal_ref_idc , nal_unit_type	1	//nal_unit_type 7
+*profile_idc					u(8)
+*constraint_set_flags			u(8)
+*level_idc						u(8)
+*seq_parameter_set_id			ue(v)
+*								//if profile_idc is one of high profiles
+*chroma_format_idc				ue(v)
+*separate_colour_plane_flag		u(1)	//if chroma_format_idc == 3
+*bit_depth_luma_minus8			ue(v)
+*bit_depth_chroma_minus8		ue(v)
+*qpprime_y_zero_transform_bypass_flag	u(1)
+*seq_scaling_matrix_present_flag	u(1)
+*scaling lists					//if seq_scaling_matrix_present_flag
+*								//end if
+*log2_max_frame_num_minus4		ue(v)
+*pic_order_cnt_type				ue(v)
+*...							//depends on pic_order_cnt_type
+*max_num_ref_frames				ue(v)
+*gaps_in_frame_num_value_allowed_flag	u(1)
+*pic_width_in_mbs_minus1		ue(v)
+*pic_height_in_map_units_minus1	ue(v)
+*frame_mbs_only_flag			u(1)
+*mb_adaptive_frame_field_flag	u(1)	//if !frame_mbs_only_flag
+*direct_8x8_inference_flag		u(1)
+*frame_cropping_flag			u(1)
+*frame_crop_left_offset			ue(v)	//if frame_cropping_flag , and following 3
+*frame_crop_right_offset		ue(v)
+*frame_crop_top_offset			ue(v)
+*frame_crop_bottom_offset		ue(v)
+*vui_parameters_present_flag	u(1)
+*vui_parameters					//if vui_parameters_present_flag
+ */
+func parseAVCSPS(nal []byte) (*AVCSPS, error) {
+	if len(nal) < 1 || nal[0]&0x1f != 7 {
+		return nil, ErrInvalidData
+	}
+	r := newBitReader(unescapeRBSP(nal[1:]))
+
+	s := &AVCSPS{
+		Profile:         uint8(r.readBits(8)),
+		ConstraintFlags: uint8(r.readBits(8)),
+		Level:           uint8(r.readBits(8)),
+		ID:              r.readUE(),
+		ChromaFormat:    1,
+		BitDepthLuma:    8,
+		BitDepthChroma:  8,
+		VUI:             defaultVUI(),
+	}
+
+	separateColourPlane := false
+	if avcHighProfiles[s.Profile] {
+		s.ChromaFormat = uint8(r.readUE())
+		if s.ChromaFormat == 3 {
+			separateColourPlane = r.readFlag()
+		}
+		s.BitDepthLuma = uint8(r.readUE()) + 8
+		s.BitDepthChroma = uint8(r.readUE()) + 8
+		r.skip(1) //qpprime_y_zero_transform_bypass_flag
+		if r.readFlag() {
+			n := 8
+			if s.ChromaFormat == 3 {
+				n = 12
+			}
+			for i := 0; i < n; i++ {
+				if !r.readFlag() {
+					continue
+				}
+				if i < 6 {
+					skipScalingList(r, 16)
+				} else {
+					skipScalingList(r, 64)
+				}
+			}
+		}
+	}
+
+	r.readUE() //log2_max_frame_num_minus4
+	picOrderCntType := r.readUE()
+	switch picOrderCntType {
+	case 0:
+		r.readUE() //log2_max_pic_order_cnt_lsb_minus4
+	case 1:
+		r.skip(1) //delta_pic_order_always_zero_flag
+		r.readSE()
+		r.readSE()
+		n := r.readUE() //num_ref_frames_in_pic_order_cnt_cycle
+		for i := uint32(0); i < n && r.err == nil; i++ {
+			r.readSE()
+		}
+	}
+	r.readUE() //max_num_ref_frames
+	r.skip(1)  //gaps_in_frame_num_value_allowed_flag
+	s.CodedWidth = r.readUE() + 1
+	s.CodedHeight = r.readUE() + 1
+	s.FrameMbsOnly = r.readFlag()
+	if !s.FrameMbsOnly {
+		r.skip(1) //mb_adaptive_frame_field_flag
+		s.CodedHeight *= 2
+	}
+	r.skip(1) //direct_8x8_inference_flag
+	if r.readFlag() {
+		cropUnitX, cropUnitY := uint32(1), uint32(1)
+		if s.ChromaFormat != 0 && !separateColourPlane {
+			if s.ChromaFormat < 3 {
+				cropUnitX = 2
+			}
+			if s.ChromaFormat == 1 {
+				cropUnitY = 2
+			}
+		}
+		if !s.FrameMbsOnly {
+			cropUnitY *= 2
+		}
+		s.CropLeft = r.readUE() * cropUnitX
+		s.CropRight = r.readUE() * cropUnitX
+		s.CropTop = r.readUE() * cropUnitY
+		s.CropBottom = r.readUE() * cropUnitY
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	width, height := s.CodedWidth*16, s.CodedHeight*16
+	if s.CropLeft+s.CropRight >= width || s.CropTop+s.CropBottom >= height {
+		return nil, ErrInvalidData
+	}
+	s.Width = width - s.CropLeft - s.CropRight
+	s.Height = height - s.CropTop - s.CropBottom
+
+	if r.readFlag() {
+		s.parseVUI(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	return s, nil
+}
+
+//skipScalingList skips scaling_list of size in r
+func skipScalingList(r *bitReader, size int) {
+	last, next := int32(8), int32(8)
+	for i := 0; i < size && r.err == nil; i++ {
+		if next != 0 {
+			next = (last + r.readSE() + 256) % 256
+		}
+		if next != 0 {
+			last = next
+		}
+	}
+}
+
+//defaultVUI return VUI with inferred values if not present
+func defaultVUI() VUI {
+	return VUI{
+		ColourPrimaries:         colourUnspecified,
+		TransferCharacteristics: colourUnspecified,
+		MatrixCoefficients:      colourUnspecified,
+	}
+}
+
+//parseVUI decodes VUI parameters in r up to timing info
+/**
+*aspect_ratio_info_present_flag	u(1)
+*aspect_ratio_idc				u(8)
+*sar_width						u(16)	//if aspect_ratio_idc == 255
+*sar_height						u(16)
+*overscan_info_present_flag		u(1)
+*overscan_appropriate_flag		u(1)
+*video_signal_type_present_flag	u(1)
+*video_format					u(3)
+*video_full_range_flag			u(1)
+*colour_description_present_flag	u(1)
+*colour_primaries				u(8)
+*transfer_characteristics		u(8)
+*matrix_coefficients			u(8)
+*chroma_loc_info_present_flag	u(1)
+*chroma_sample_loc_type_top_field	ue(v)
+*chroma_sample_loc_type_bottom_field	ue(v)
+*timing_info_present_flag		u(1)
+*num_units_in_tick				u(32)
+*time_scale						u(32)
+*fixed_frame_rate_flag			u(1)
+ */
+func (s *AVCSPS) parseVUI(r *bitReader) {
+	s.VUI.parse(r)
+	if r.readFlag() {
+		s.NumUnitsInTick = uint32(r.readBits(32))
+		s.TimeScale = uint32(r.readBits(32))
+		s.FixedFrameRate = r.readFlag()
+	}
+}
+
+//parse decodes VUI parameters in r up to chroma sample location , common in AVC and HEVC
+func (v *VUI) parse(r *bitReader) {
+	if r.readFlag() {
+		idc := uint8(r.readBits(8))
+		if idc == avcExtendedSAR {
+			v.SARWidth = uint16(r.readBits(16))
+			v.SARHeight = uint16(r.readBits(16))
+		} else if int(idc) < len(avcSampleAspectRatios) {
+			v.SARWidth, v.SARHeight = avcSampleAspectRatios[idc][0], avcSampleAspectRatios[idc][1]
+		}
+	}
+	if r.readFlag() {
+		r.skip(1) //overscan_appropriate_flag
+	}
+	if r.readFlag() {
+		r.skip(3) //video_format
+		v.VideoFullRange = r.readFlag()
+		if r.readFlag() {
+			v.ColourPrimaries = uint8(r.readBits(8))
+			v.TransferCharacteristics = uint8(r.readBits(8))
+			v.MatrixCoefficients = uint8(r.readBits(8))
+		}
+	}
+	if r.readFlag() {
+		r.readUE() //chroma_sample_loc_type_top_field
+		r.readUE()
+	}
+}
@@ -0,0 +1,103 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+//testHighSPS is SPS of high profile , level 4.0 , 1920x1080 cropped from 1920x1088 ,
+//	with sample aspect ratio 4:3 , BT.2020 PQ colour and 23.976 fps timing
+var testHighSPS = []byte{
+	0x67, 0x64, 0x00, 0x28, 0xac, 0xe5, 0x01, 0xe0, 0x08, 0x9f, 0x97, 0xff, 0x00, 0x04, 0x00,
+	0x03, 0x6a, 0x12, 0x20, 0x12, 0x80, 0x00, 0x01, 0xf4, 0x80, 0x00, 0x5d, 0xc0, 0x60,
+}
+
+//mkAVCC return data of avcC box of high profile with sps and pps
+func mkAVCC(sps, pps []byte) []byte {
+	return bytes.Join([][]byte{
+		{1, sps[1], sps[2], sps[3], 0xff, 0xe1}, be(uint16(len(sps))), sps,
+		{1}, be(uint16(len(pps))), pps,
+		{0xfd, 0xf8, 0xf8, 0},
+	}, nil)
+}
+
+func TestParseAVCConfig(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := info.Tracks()[0].Video()
+	if video.AVC == nil || video.AVC.NALLengthSize != 4 || len(video.AVC.SPS) != 1 || len(video.AVC.PPS) != 1 {
+		t.Fatalf("got %+v", video.AVC)
+	}
+	sps := video.AVC.SPSInfo
+	if sps.Profile != 66 || sps.Level != 30 || sps.Width != 560 || sps.Height != 320 ||
+		sps.ChromaFormat != 1 || sps.BitDepthLuma != 8 || !sps.FrameMbsOnly || sps.FrameRate() != 30 {
+		t.Errorf("got %+v", sps)
+	}
+	if got := info.Tracks()[0].CodecString(); got != "avc1.42C01E" {
+		t.Errorf("CodecString,want avc1.42C01E , got %s", got)
+	}
+
+	got, err := parseAVCConfig(mkAVCC(testHighSPS, []byte{0x68, 0xee, 0x3c, 0x80}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := got.(*AVCConfig)
+	if c.Profile != 100 || c.Level != 40 || c.ChromaFormat != 1 || c.BitDepthLuma != 8 || c.BitDepthChroma != 8 ||
+		!bytes.Equal(c.PPS[0], []byte{0x68, 0xee, 0x3c, 0x80}) || len(c.SPSExt) != 0 {
+		t.Errorf("got %+v", c)
+	}
+	want := AVCSPS{
+		Profile: 100, Level: 40, ChromaFormat: 1, BitDepthLuma: 8, BitDepthChroma: 8, FrameMbsOnly: true,
+		CodedWidth: 120, CodedHeight: 68, CropBottom: 8, Width: 1920, Height: 1080,
+		NumUnitsInTick: 1001, TimeScale: 48000, FixedFrameRate: true,
+		VUI: VUI{SARWidth: 4, SARHeight: 3, ColourPrimaries: 9, TransferCharacteristics: 16, MatrixCoefficients: 9},
+	}
+	if *c.SPSInfo != want {
+		t.Errorf("want %+v , got %+v", want, *c.SPSInfo)
+	}
+	if rate := c.SPSInfo.FrameRate(); rate < 23.97 || rate > 23.98 {
+		t.Errorf("FrameRate,got %f", rate)
+	}
+
+	for _, data := range [...][]byte{
+		{1, 0x42, 0xc0, 0x1e, 0xff},
+		{1, 0x42, 0xc0, 0x1e, 0xff, 0xe1, 0, 10, 0x67},
+	} {
+		if _, err := parseAVCConfig(data); err == nil {
+			t.Errorf("parseAVCConfig(%x),want error", data)
+		}
+	}
+
+	//config is kept if SPS fails to decode
+	for _, data := range [...][]byte{
+		{1, 0x42, 0xc0, 0x1e, 0xff, 0xe1, 0, 1, 0x68, 0}, //not SPS
+		mkAVCC(testHighSPS[:10], nil),
+	} {
+		got, err := parseAVCConfig(data)
+		if err != nil {
+			t.Errorf("parseAVCConfig(%x),got error %v", data, err)
+			continue
+		}
+		if c := got.(*AVCConfig); len(c.SPS) != 1 || c.SPSInfo != nil {
+			t.Errorf("parseAVCConfig(%x),want SPS without SPSInfo , got %+v", data, c)
+		}
+	}
+}
+
+func TestUnescapeRBSP(t *testing.T) {
+	tests := [...]struct {
+		data, want []byte
+	}{
+		{[]byte{0, 0, 3, 1}, []byte{0, 0, 1}},
+		{[]byte{0, 0, 3, 0, 0, 3}, []byte{0, 0, 0, 0}},
+		{[]byte{0, 3, 0, 3}, []byte{0, 3, 0, 3}},
+		{[]byte{0, 0, 0, 3}, []byte{0, 0, 0}},
+	}
+	for _, test := range tests {
+		if got := unescapeRBSP(test.data); !bytes.Equal(got, test.want) {
+			t.Errorf("unescapeRBSP(%x),want %x , got %x", test.data, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package mp4parser
+
+//bitReader reads bits from data , most significant bit first ,
+//	reading beyond data sets err to ErrTruncated and returns zero afterwards
+type bitReader struct {
+	data []byte
+	pos  int //in bits
+	err  error
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{
+		data: data,
+	}
+}
+
+//readBits return next n bits , n <= 64
+func (r *bitReader) readBits(n int) uint64 {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos+n > len(r.data)*8 {
+		r.err = ErrTruncated
+		return 0
+	}
+
+	var v uint64
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos>>3] >> (7 - uint(r.pos&7)) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+//readFlag return next bit as bool
+func (r *bitReader) readFlag() bool {
+	return r.readBits(1) == 1
+}
+
+//skip skips next n bits
+func (r *bitReader) skip(n int) {
+	if r.err != nil {
+		return
+	}
+	if r.pos+n > len(r.data)*8 {
+		r.err = ErrTruncated
+		return
+	}
+	r.pos += n
+}
+
+//bitsLeft return number of bits not read yet
+func (r *bitReader) bitsLeft() int {
+	return len(r.data)*8 - r.pos
+}
+
+//readUE return next Exp-Golomb coded unsigned integer , ue(v) , up to 32 bits
+func (r *bitReader) readUE() uint32 {
+	leadingZeros := 0
+	for !r.readFlag() {
+		if r.err != nil {
+			return 0
+		}
+		if leadingZeros++; leadingZeros > 31 {
+			r.err = ErrInvalidData
+			return 0
+		}
+	}
+	return uint32(1<<uint(leadingZeros)-1) + uint32(r.readBits(leadingZeros))
+}
+
+//readSE return next Exp-Golomb coded signed integer , se(v)
+func (r *bitReader) readSE() int32 {
+	v := r.readUE()
+	if v&1 == 1 {
+		return int32(v/2) + 1
+	}
+	return -int32(v / 2)
+}
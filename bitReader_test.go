@@ -0,0 +1,46 @@
+package mp4parser
+
+import "testing"
+
+func TestBitReader(t *testing.T) {
+	r := newBitReader([]byte{0xa5, 0x0f, 0xff})
+
+	if got := r.readBits(3); got != 5 {
+		t.Errorf("readBits(3),want 5 , got %d", got)
+	}
+	if got := r.readFlag(); got {
+		t.Errorf("readFlag,want false , got %v", got)
+	}
+	r.skip(4)
+	if got := r.readBits(12); got != 0x0ff {
+		t.Errorf("readBits(12),want 0xff , got %#x", got)
+	}
+	if got := r.bitsLeft(); got != 4 {
+		t.Errorf("bitsLeft,want 4 , got %d", got)
+	}
+
+	if got := r.readBits(5); got != 0 || r.err != ErrTruncated {
+		t.Errorf("read beyond data,got %d , %v", got, r.err)
+	}
+	if got := r.readBits(1); got != 0 || r.err != ErrTruncated {
+		t.Errorf("want sticky error , got %d , %v", got, r.err)
+	}
+}
+
+func TestBitReaderExpGolomb(t *testing.T) {
+	//1 , 010 , 011 , 00100 , 00101
+	r := newBitReader([]byte{0xa6, 0x42, 0x80})
+	if got := r.readUE(); got != 0 {
+		t.Errorf("readUE,want 0 , got %d", got)
+	}
+	for _, want := range [...]int32{1, -1, 2, -2} {
+		if got := r.readSE(); got != want {
+			t.Errorf("readSE,want %d , got %d", want, got)
+		}
+	}
+
+	r = newBitReader(make([]byte, 5))
+	if got := r.readUE(); got != 0 || r.err != ErrInvalidData {
+		t.Errorf("more than 31 leading zeros,got %d , %v", got, r.err)
+	}
+}
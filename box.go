@@ -4,8 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"os"
-	"strings"
+	"io"
 	"time"
 )
 
@@ -16,10 +15,13 @@ const (
 
 //Box  fundation unit in mp4
 type Box struct {
-	*header       // header / largeHeader
-	nth       int //n-th inner Box
-	innerBoxs map[string][]*Box
-	offset    int64 //offset in file
+	*header                     // header / largeHeader
+	nth       int               //n-th inner Box
+	innerBoxs []*Box            //inner boxs in file order
+	typeIndex map[string][]*Box //inner boxs by type , in file order
+	offset    int64             //offset in file
+	parent    *Box
+	payload   interface{} //decoded data , see RegisterDecoder
 }
 
 //RootBox contains all box in file
@@ -36,6 +38,7 @@ type header struct {
 	size       uint64 //size of box include header
 	headerSize int
 	boxType    string
+	userType   [16]byte //extended type of "uuid" box
 }
 
 //newBox return new Box
@@ -43,7 +46,7 @@ func newBox() *Box {
 
 	return &Box{
 		header:    &header{headerSize: normalHeaderSize},
-		innerBoxs: make(map[string][]*Box),
+		typeIndex: make(map[string][]*Box),
 	}
 }
 
@@ -58,11 +61,9 @@ func newRootBox() *RootBox {
 
 //newInnerBox  add new innerbox
 func (b *Box) addInnerBox(inner *Box) {
-	if _, ok := b.innerBoxs[inner.boxType]; !ok { //not exist yet
-		b.innerBoxs[inner.boxType] = make([]*Box, 0, 1)
-
-	}
-	b.innerBoxs[inner.boxType] = append(b.innerBoxs[inner.boxType], inner)
+	inner.parent = b
+	b.innerBoxs = append(b.innerBoxs, inner)
+	b.typeIndex[inner.boxType] = append(b.typeIndex[inner.boxType], inner)
 
 }
 
@@ -75,15 +76,108 @@ func (b *Box) String() string {
 	if len(b.innerBoxs) > 0 {
 		buffer.WriteString(fmt.Sprintf("%*sinner boxs\n", b.nth*2-1, "")) //indent
 	}
-	for _, innerBoxSlice := range b.innerBoxs {
-		for _, innerBox := range innerBoxSlice {
-			buffer.WriteString(fmt.Sprintf("%*s%v", b.nth, "", innerBox))
-		}
+	for _, innerBox := range b.innerBoxs {
+		buffer.WriteString(fmt.Sprintf("%*s%v", b.nth, "", innerBox))
 	}
 
 	return buffer.String()
 }
 
+//findBox return the first box found by types from b's inner boxs , e.g. findBox("mdia","hdlr") ,
+//	return a ParseError if not found
+func (b *Box) findBox(types ...string) (*Box, error) {
+	for _, boxType := range types {
+		inner := b.typeIndex[boxType]
+		if len(inner) == 0 {
+			return nil, newParseError(b, fmt.Errorf("%w: %s", ErrMissingBox, boxType))
+		}
+		b = inner[0]
+	}
+	return b, nil
+}
+
+//Type return type of b , e.g. "moov"
+func (b *Box) Type() string {
+	return b.boxType
+}
+
+//Size return size of b including header
+func (b *Box) Size() uint64 {
+	return b.size
+}
+
+//HeaderSize return size of b's header
+func (b *Box) HeaderSize() int {
+	return b.headerSize
+}
+
+//Offset return offset of b in file
+func (b *Box) Offset() int64 {
+	return b.offset
+}
+
+//UserType return extended type of "uuid" box , zero for other types
+func (b *Box) UserType() [16]byte {
+	return b.userType
+}
+
+//Payload return data decoded by the decoder registered for b's type , nil if there is not any ,
+//	see RegisterDecoder
+func (b *Box) Payload() interface{} {
+	return b.payload
+}
+
+//Children return inner boxs of b in file order
+func (b *Box) Children() []*Box {
+	children := make([]*Box, len(b.innerBoxs))
+	copy(children, b.innerBoxs)
+	return children
+}
+
+//Parent return the box containing b , nil if b is root
+func (b *Box) Parent() *Box {
+	return b.parent
+}
+
+//Path return path of b from root , e.g. moov/trak[1]/mdia/hdlr , which can be used by Query
+func (b *Box) Path() string {
+	return b.path()
+}
+
+//dataSize return size of b's data , which follows header
+func (b *Box) dataSize() int64 {
+	return int64(b.size) - int64(b.headerSize)
+}
+
+//path return path of b from root , e.g. moov/trak[1]/mdia/hdlr ,
+//	index is shown only if there are more than one box of the same type
+func (b *Box) path() string {
+	if b.parent == nil {
+		return ""
+	}
+	if b.boxType == "" { //header not parsed
+		return b.parent.path()
+	}
+
+	name := b.boxType
+	siblings := b.parent.typeIndex[b.boxType]
+	index := len(siblings) //not added yet
+	for i, sibling := range siblings {
+		if sibling == b {
+			index = i
+			break
+		}
+	}
+	if index > 0 || len(siblings) > 1 {
+		name = fmt.Sprintf("%s[%d]", name, index)
+	}
+
+	if parentPath := b.parent.path(); parentPath != "" {
+		return parentPath + "/" + name
+	}
+	return name
+}
+
 func (r *RootBox) String() string {
 	return r.Box.String()
 }
@@ -92,19 +186,37 @@ func (h *header) String() string {
 	return fmt.Sprintf("size: %d\theader size:%d\ttype: %s", h.size, h.headerSize, h.boxType)
 }
 
-//
+//containers maps type of container box to size of fields before its inner boxs ,
+//	e.g. version and flags of full box , entry count
+var containers = map[string]int{
+	"moov": 0, "trak": 0, "mdia": 0, "minf": 0, "dinf": 0, "stbl": 0,
+	"edts": 0, "udta": 0, "tref": 0, "gmhd": 0, "trgr": 0,
+	"mvex": 0, "moof": 0, "traf": 0, "mfra": 0,
+	"sinf": 0, "schi": 0, "rinf": 0,
+	"ilst": 0, "iprp": 0, "ipco": 0,
+	"meta": 4, //version/flags , absent in QuickTime
+	"stsd": 8, //version/flags , entry count
+	"dref": 8,
+	"iinf": 6, //version/flags , entry count of 2 bytes in version 0 , 4 bytes otherwise
+}
+
+//isContainer reports whether b contains inner boxs
 func (b *Box) isContainer() bool {
-	if strings.TrimSpace(b.boxType) == "" {
-		return false
+	if _, ok := containers[b.boxType]; ok {
+		return true
+	}
+	if b.isSampleEntry() {
+		return true
 	}
-	return strings.Contains("moov trak mdia minf dinf stbl", b.boxType)
+	//items of metadata list , e.g. "\xa9nam" , contain "data" boxs
+	return b.parent != nil && b.parent.boxType == "ilst"
 }
 
 //=====specified box types=======//
 
 //boxs contain meta data
 type dataBox interface {
-	scan(*os.File) error
+	scan(io.ReaderAt) error
 }
 
 //moov movie
@@ -117,10 +229,10 @@ type moov struct {
 *header				normalHeaderSize/largeHeaderSize
 *version 			1
 *flags 				3
-*creation_time		4
-*modification_time	4
+*creation_time		4	//8 if version == 1
+*modification_time	4	//8 if version == 1
 *time_scale			4
-*duration 			4
+*duration 			4	//8 if version == 1
 *rate 				4
 *volume 			2
 *_reserved 			10
@@ -133,7 +245,7 @@ type mvhd struct {
 	version      uint8
 	creationTime *time.Time
 	modifTime    *time.Time
-	duration     uint32
+	duration     uint64
 	timeScale    uint32
 	// nextTrackID uint32
 }
@@ -144,31 +256,52 @@ func newMVHD(b *Box) *mvhd {
 	}
 }
 
-//scan mvhd data in file , return an error ,if any , and resume file seeker
-func (b *mvhd) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
+//scan mvhd data in r , return an error ,if any
+func (b *mvhd) scan(r io.ReaderAt) (err error) {
+	temp := new([32]byte)
 
-	temp := new([16]byte)
-
-	_, err = file.ReadAt(temp[:], b.offset+int64(b.headerSize)+4) //read at creation_time
+	if b.dataSize() < 4 {
+		return ErrTruncated
+	}
+	err = readAt(r, temp[:4], b.offset+int64(b.headerSize)) //read at version
 	if err != nil {
 		return
 	}
 
-	b.creationTime, err = getFixTime(binary.BigEndian.Uint32(temp[:4]))
-	if err != nil {
-		return
+	var creationTime, modifTime uint64
+	switch b.version = temp[0]; b.version {
+	case 0:
+		if b.dataSize() < 20 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:20], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = uint64(binary.BigEndian.Uint32(temp[4:8]))
+		modifTime = uint64(binary.BigEndian.Uint32(temp[8:12]))
+		b.timeScale = binary.BigEndian.Uint32(temp[12:16])
+		b.duration = uint64(binary.BigEndian.Uint32(temp[16:20]))
+	case 1:
+		if b.dataSize() < 32 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:32], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = binary.BigEndian.Uint64(temp[4:12])
+		modifTime = binary.BigEndian.Uint64(temp[12:20])
+		b.timeScale = binary.BigEndian.Uint32(temp[20:24])
+		b.duration = binary.BigEndian.Uint64(temp[24:32])
+	default:
+		return ErrUnsupportedVersion
 	}
 
-	b.modifTime, err = getFixTime(binary.BigEndian.Uint32(temp[4:8]))
+	b.creationTime, err = getFixTime(creationTime)
 	if err != nil {
 		return
 	}
 
-	b.timeScale = binary.BigEndian.Uint32(temp[8:12])
-
-	b.duration = binary.BigEndian.Uint32(temp[12:16])
+	b.modifTime, err = getFixTime(modifTime)
 
 	return
 }
@@ -194,11 +327,11 @@ const (
 *header				normalHeaderSize/largeHeaderSize
 *version			1
 *flags				3
-*creation_time		4
-*modification_time	4
+*creation_time		4	//8 if version == 1
+*modification_time	4	//8 if version == 1
 *track_id			4
 *_reserved			4
-*duration			4
+*duration			4	//8 if version == 1
 *_reserved			8
 *layer				2
 *alternate_group	2
@@ -210,15 +343,15 @@ const (
  */
 type tkhd struct {
 	*Box
-	// version      uint8
+	version      uint8
 	flags        uint32
 	creationTime *time.Time
 	modifTime    *time.Time
-	duration     uint32
-	// trackID      uint32
-	volume float64
-	width  float64
-	height float64
+	trackID      uint32
+	duration     uint64
+	volume       float64
+	width        float64
+	height       float64
 }
 
 func newTKHD(b *Box) *tkhd {
@@ -227,47 +360,69 @@ func newTKHD(b *Box) *tkhd {
 	}
 }
 
-//scan tkhd data in file , return an error ,if any , and resume file seeker
-func (b *tkhd) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
-
-	temp := new([40]byte)
+//scan tkhd data in r , return an error ,if any
+func (b *tkhd) scan(r io.ReaderAt) (err error) {
+	temp := new([84]byte)
 
-	_, err = file.ReadAt(temp[:40], b.offset+int64(b.headerSize)) //read at version
+	if b.dataSize() < 4 {
+		return ErrTruncated
+	}
+	err = readAt(r, temp[:4], b.offset+int64(b.headerSize)) //read at version
 	if err != nil {
 		return
 	}
 
-	// if version := binary.BigEndian.Uint16(temp[:1]); version == 1 {
-	// 	panic("scan tkhd: version = 1")
-	// }
+	b.version = temp[0]
+	b.flags = uint32(temp[1])<<16 | uint32(temp[2])<<8 | uint32(temp[3])
 
-	// b.flags=temp[1:4]
-	b.creationTime, err = getFixTime(binary.BigEndian.Uint32(temp[4:8]))
-	if err != nil {
-		return
-	}
+	var creationTime, modifTime uint64
+	var rest []byte //from the _reserved following duration
+	switch b.version {
+	case 0:
+		if b.dataSize() < 84 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:84], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = uint64(binary.BigEndian.Uint32(temp[4:8]))
+		modifTime = uint64(binary.BigEndian.Uint32(temp[8:12]))
+		b.trackID = binary.BigEndian.Uint32(temp[12:16])
+		b.duration = uint64(binary.BigEndian.Uint32(temp[20:24]))
+		rest = temp[24:84]
+	case 1:
+		if b.dataSize() < 96 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:36], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = binary.BigEndian.Uint64(temp[4:12])
+		modifTime = binary.BigEndian.Uint64(temp[12:20])
+		b.trackID = binary.BigEndian.Uint32(temp[20:24])
+		b.duration = binary.BigEndian.Uint64(temp[28:36])
 
-	b.modifTime, err = getFixTime(binary.BigEndian.Uint32(temp[8:12]))
-	if err != nil {
-		return
-	}
-	b.duration = binary.BigEndian.Uint32(temp[20:24])
-	b.volume, err = dottedNotationToF(temp[36:38])
-	if err != nil {
-		return
+		rest = temp[:60]
+		if err = readAt(r, rest, b.offset+int64(b.headerSize)+36); err != nil {
+			return
+		}
+	default:
+		return ErrUnsupportedVersion
 	}
 
-	_, err = file.ReadAt(temp[:8], b.offset+int64(b.headerSize)+76) //read at width
+	b.creationTime, err = getFixTime(creationTime)
 	if err != nil {
 		return
 	}
-	b.width, err = dottedNotationToF(temp[:4])
+
+	b.modifTime, err = getFixTime(modifTime)
 	if err != nil {
 		return
 	}
-	b.height, err = dottedNotationToF(temp[4:8])
+
+	b.volume = fixed16ToF(binary.BigEndian.Uint16(rest[12:14]))
+	b.width = fixed32ToF(binary.BigEndian.Uint32(rest[52:56]))
+	b.height = fixed32ToF(binary.BigEndian.Uint32(rest[56:60]))
 
 	return
 }
@@ -282,20 +437,21 @@ type mdia struct {
 *header					normalHeaderSize/largeHeaderSize
 *version				1
 *flags					3
-*creation_time			4
-*modification_time		4
+*creation_time			4	//8 if version == 1
+*modification_time		4	//8 if version == 1
 *time_scale				4
-*duration				4
+*duration				4	//8 if version == 1
 *language				2
 *pre-defined			2
  */
 type mdhd struct {
 	*Box
-	// version uint8
-	// creationTime *time.Time
-	// modifTime    *time.Time
-	// duration  *time.Duration
-	timeScale uint32
+	version      uint8
+	creationTime *time.Time
+	modifTime    *time.Time
+	timeScale    uint32
+	duration     uint64
+	language     string //ISO 639-2/T code , e.g. "und"
 }
 
 func newMDHD(b *Box) *mdhd {
@@ -304,22 +460,73 @@ func newMDHD(b *Box) *mdhd {
 	}
 }
 
-func (b *mdhd) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
+//scan mdhd data in r , return an error ,if any
+func (b *mdhd) scan(r io.ReaderAt) (err error) {
+	temp := new([34]byte)
 
-	temp := make([]byte, 0, 4)
+	if b.dataSize() < 4 {
+		return ErrTruncated
+	}
+	err = readAt(r, temp[:4], b.offset+int64(b.headerSize)) //read at version
+	if err != nil {
+		return
+	}
 
-	_, err = file.ReadAt(temp[:4], b.offset+int64(b.headerSize)+12)
+	var creationTime, modifTime uint64
+	var language []byte
+	switch b.version = temp[0]; b.version {
+	case 0:
+		if b.dataSize() < 22 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:22], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = uint64(binary.BigEndian.Uint32(temp[4:8]))
+		modifTime = uint64(binary.BigEndian.Uint32(temp[8:12]))
+		b.timeScale = binary.BigEndian.Uint32(temp[12:16])
+		b.duration = uint64(binary.BigEndian.Uint32(temp[16:20]))
+		language = temp[20:22]
+	case 1:
+		if b.dataSize() < 34 {
+			return ErrTruncated
+		}
+		if err = readAt(r, temp[:34], b.offset+int64(b.headerSize)); err != nil {
+			return
+		}
+		creationTime = binary.BigEndian.Uint64(temp[4:12])
+		modifTime = binary.BigEndian.Uint64(temp[12:20])
+		b.timeScale = binary.BigEndian.Uint32(temp[20:24])
+		b.duration = binary.BigEndian.Uint64(temp[24:32])
+		language = temp[32:34]
+	default:
+		return ErrUnsupportedVersion
+	}
+
+	b.language = decodeLanguage(binary.BigEndian.Uint16(language))
+
+	b.creationTime, err = getFixTime(creationTime)
 	if err != nil {
 		return
 	}
 
-	b.timeScale = binary.BigEndian.Uint32(temp[:4])
+	b.modifTime, err = getFixTime(modifTime)
 
 	return
 }
 
+//decodeLanguage return ISO 639-2/T code packed in 3*5 bits , each is offset of 0x60
+func decodeLanguage(packed uint16) string {
+	if packed < 0x400 || packed == 0x7fff { //Macintosh language code or unspecified
+		return "und"
+	}
+	return string([]byte{
+		byte(packed>>10&0x1f) + 0x60,
+		byte(packed>>5&0x1f) + 0x60,
+		byte(packed&0x1f) + 0x60,
+	})
+}
+
 //hdlr handler reference
 /**
 *header				normalHeaderSize/largeHeaderSize
@@ -343,41 +550,37 @@ func newHDLR(b *Box) *hdlr {
 	}
 }
 
-func (b *hdlr) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
-
+//scan hdlr data in r , return an error ,if any
+func (b *hdlr) scan(r io.ReaderAt) (err error) {
 	temp := make([]byte, 0, 4)
 
-	_, err = file.ReadAt(temp[:4], b.offset+int64(b.headerSize)+8)
+	if b.dataSize() < 12 { //version , flags , pre-defined and handler_type
+		return ErrTruncated
+	}
+	err = readAt(r, temp[:4], b.offset+int64(b.headerSize)+8)
 	if err != nil {
 		return
 	}
 
 	b.handlerType = string(temp[:4])
 
-	_, err = file.Seek(b.offset+int64(b.headerSize)+24, seekFromStart)
-
+	//read name
+	nameSize := b.dataSize() - 24
+	if nameSize <= 0 {
+		return
+	}
+	temp = make([]byte, nameSize)
+	err = readAt(r, temp, b.offset+int64(b.headerSize)+24)
 	if err != nil {
 		return
 	}
-	//read name
-	temp = temp[:0]
-	t := make([]byte, 1)
 
-	for {
-
-		n, err := file.Read(t)
-		if err != nil || n == 0 {
-			return err
-		}
-
-		if t[0] == '\000' {
-			break
-		}
-		temp = append(temp, t[0])
+	if int(temp[0]) == len(temp)-1 { //QuickTime uses pascal string
+		temp = temp[1:]
+	}
+	if i := bytes.IndexByte(temp, '\000'); i >= 0 {
+		temp = temp[:i]
 	}
-
 	b.name = string(temp)
 
 	return
@@ -452,38 +655,32 @@ func (b *stsc) String() string {
 	return buffer.String()
 }
 
-//scan stsc data in file , return an error ,if any , and resume file seeker
-func (b *stsc) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
-
-	temp := new([12]byte)
-
-	_, err = file.Seek(b.offset+int64(b.headerSize)+4, seekFromStart)
-	if err != nil {
-		return
-	}
+//scan stsc data in r , return an error ,if any
+func (b *stsc) scan(r io.ReaderAt) (err error) {
+	temp := new([4]byte)
 
-	_, err = file.Read(temp[:4]) //read entry count
+	err = readAt(r, temp[:4], b.offset+int64(b.headerSize)+4) //read entry count
 	if err != nil {
 		return
 	}
 
 	b.entryCount = binary.BigEndian.Uint32(temp[:4])
-	if b.entrys == nil {
-		b.entrys = make([]*stscEntry, 0, b.entryCount)
+	if int64(b.entryCount)*12 > b.dataSize()-8 {
+		return ErrTruncated
 	}
 
-	for i := uint32(0); i < b.entryCount; i++ {
+	data := make([]byte, int(b.entryCount)*12)
+	err = readAt(r, data, b.offset+int64(b.headerSize)+8)
+	if err != nil {
+		return
+	}
 
-		_, err = file.Read(temp[:])
-		if err != nil {
-			return
-		}
+	b.entrys = make([]*stscEntry, 0, b.entryCount)
+	for i := 0; i < len(data); i += 12 {
 		b.entrys = append(b.entrys, &stscEntry{
-			firstChunk:      binary.BigEndian.Uint32(temp[:4]),
-			samplesPerChunk: binary.BigEndian.Uint32(temp[4:8]),
-			sampleDescIndex: binary.BigEndian.Uint32(temp[8:12]),
+			firstChunk:      binary.BigEndian.Uint32(data[i : i+4]),
+			samplesPerChunk: binary.BigEndian.Uint32(data[i+4 : i+8]),
+			sampleDescIndex: binary.BigEndian.Uint32(data[i+8 : i+12]),
 		})
 
 	}
@@ -491,19 +688,19 @@ func (b *stsc) scan(file *os.File) (err error) {
 	return
 }
 
-//stco chunk offset
+//stco chunk offset , and co64 of 64 bits offsets
 /**
 *header			normalHeaderSize/largeHeaderSize
 *version		1
 *flags 			3
 *entryCount		4
-*chunkOffset	entryCount*4
+*chunkOffset	entryCount*4	//entryCount*8 in co64
  */
 type stco struct {
 	*Box
 	// version     uint8
 	entryCount  uint32
-	chunkOffset []uint32
+	chunkOffset []uint64
 }
 
 func newSTCO(b *Box) *stco {
@@ -526,38 +723,44 @@ func (b *stco) String() string {
 	return buffer.String()
 }
 
-//scan stco data in file , return an error ,if any , and resume file seeker
-func (b *stco) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
-
+//scan stco data in r , return an error ,if any
+func (b *stco) scan(r io.ReaderAt) (err error) {
 	temp := new([4]byte)
-	_, err = file.Seek(b.offset+int64(b.headerSize)+4, seekFromStart)
 
-	_, err = file.Read(temp[:]) //read entry count
+	err = readAt(r, temp[:], b.offset+int64(b.headerSize)+4) //read entry count
 	if err != nil {
 		return
 	}
 
+	entrySize := 4
+	if b.boxType == "co64" {
+		entrySize = 8
+	}
+
 	b.entryCount = binary.BigEndian.Uint32(temp[:])
-	if b.chunkOffset == nil {
-		b.chunkOffset = make([]uint32, 0, b.entryCount)
+	if int64(b.entryCount)*int64(entrySize) > b.dataSize()-8 {
+		return ErrTruncated
 	}
 
-	for i := uint32(0); i < b.entryCount; i++ {
+	data := make([]byte, int(b.entryCount)*entrySize)
+	err = readAt(r, data, b.offset+int64(b.headerSize)+8)
+	if err != nil {
+		return
+	}
 
-		_, err = file.Read(temp[:])
-		if err != nil {
-			return
+	b.chunkOffset = make([]uint64, 0, b.entryCount)
+	for i := 0; i < len(data); i += entrySize {
+		if entrySize == 8 {
+			b.chunkOffset = append(b.chunkOffset, binary.BigEndian.Uint64(data[i:i+8]))
+		} else {
+			b.chunkOffset = append(b.chunkOffset, uint64(binary.BigEndian.Uint32(data[i:i+4])))
 		}
-		b.chunkOffset = append(b.chunkOffset, binary.BigEndian.Uint32(temp[:]))
-
 	}
 
 	return
 }
 
-//stsz  sample size
+//stsz  sample size , and stz2 of compact sample size
 /**
 *header
 *version			1
@@ -565,13 +768,20 @@ func (b *stco) scan(file *os.File) (err error) {
 *sample_size		4//if it have the value 0,sample have different size as shown in following sample_size
 *entry_count		4//count of following sample_size
 *sample_size		4*sample_count
+*
+*					//stz2
+*_reserved			3
+*field_size			1//4 , 8 or 16 bits
+*sample_count		4
+*entry_size			field_size*sample_count , padded to byte
  */
 
 type stsz struct {
 	*Box
 	// version int
-	entryCount uint32
-	sampleSize []uint32
+	sampleCount uint32
+	entryCount  uint32
+	sampleSize  []uint32
 }
 
 func newSTSZ(b *Box) *stsz {
@@ -580,43 +790,350 @@ func newSTSZ(b *Box) *stsz {
 	}
 }
 
-func (b *stsz) scan(file *os.File) (err error) {
-	savedOffset, _ := file.Seek(0, seekFromCurrent)
-	defer file.Seek(savedOffset, seekFromStart)
-
-	temp := new([4]byte)
-	_, err = file.Seek(b.offset+int64(b.headerSize)+4, seekFromStart)
+//scan stsz data in r , return an error ,if any
+func (b *stsz) scan(r io.ReaderAt) (err error) {
+	temp := new([8]byte)
 
-	_, err = file.Read(temp[:4]) //
+	err = readAt(r, temp[:8], b.offset+int64(b.headerSize)+4) //read sample_size and entry_count
 	if err != nil {
 		return
 	}
 
+	b.sampleCount = binary.BigEndian.Uint32(temp[4:8])
+	if b.boxType == "stz2" {
+		return b.scanCompact(r, temp[3])
+	}
+
 	if binary.BigEndian.Uint32(temp[:4]) != 0 { //all samples have the same size
 		b.entryCount = 0
 		b.sampleSize = []uint32{binary.BigEndian.Uint32(temp[:4])}
 		return
 	}
 
-	_, err = file.Read(temp[:4]) //
+	b.entryCount = binary.BigEndian.Uint32(temp[4:8])
+	if int64(b.entryCount)*4 > b.dataSize()-12 {
+		return ErrTruncated
+	}
+
+	data := make([]byte, int(b.entryCount)*4)
+	err = readAt(r, data, b.offset+int64(b.headerSize)+12)
 	if err != nil {
 		return
 	}
 
-	b.entryCount = binary.BigEndian.Uint32(temp[:4])
-	if b.sampleSize == nil {
-		b.sampleSize = make([]uint32, 0, b.entryCount)
+	b.sampleSize = make([]uint32, 0, b.entryCount)
+	for i := 0; i < len(data); i += 4 {
+		b.sampleSize = append(b.sampleSize, binary.BigEndian.Uint32(data[i:i+4]))
 	}
 
-	for i := uint32(0); i < b.entryCount; i++ {
+	return
+}
 
-		_, err = file.Read(temp[:4])
-		if err != nil {
-			return
+//scanCompact scan stz2 data of fieldSize bits entries in r , return an error ,if any
+func (b *stsz) scanCompact(r io.ReaderAt, fieldSize uint8) (err error) {
+	if fieldSize != 4 && fieldSize != 8 && fieldSize != 16 {
+		return ErrInvalidData
+	}
+
+	b.entryCount = b.sampleCount
+	size := (int64(b.entryCount)*int64(fieldSize) + 7) / 8
+	if size > b.dataSize()-12 {
+		return ErrTruncated
+	}
+
+	data := make([]byte, size)
+	err = readAt(r, data, b.offset+int64(b.headerSize)+12)
+	if err != nil {
+		return
+	}
+
+	b.sampleSize = make([]uint32, 0, b.entryCount)
+	for i := 0; i < int(b.entryCount); i++ {
+		switch fieldSize {
+		case 4:
+			b.sampleSize = append(b.sampleSize, uint32(data[i/2]>>(4-4*uint(i%2))&0xf))
+		case 8:
+			b.sampleSize = append(b.sampleSize, uint32(data[i]))
+		case 16:
+			b.sampleSize = append(b.sampleSize, uint32(binary.BigEndian.Uint16(data[i*2:i*2+2])))
+		}
+	}
+
+	return
+}
+
+//size return size of the nth sample , 0-based , 0 if n is out of range
+func (b *stsz) size(n uint32) uint32 {
+	if b.entryCount == 0 && len(b.sampleSize) == 1 { //all samples have the same size
+		if n < b.sampleCount {
+			return b.sampleSize[0]
+		}
+		return 0
+	}
+	if n < uint32(len(b.sampleSize)) {
+		return b.sampleSize[n]
+	}
+	return 0
+}
+
+//stts decoding time to sample
+/**
+*header			normalHeaderSize/largeHeaderSize
+*version		1
+*flags			3
+*entryCount		4
+*for each entry
+*	sampleCount	4
+*	sampleDelta	4	//in time scale of media
+ */
+type stts struct {
+	*Box
+	entryCount uint32
+	entrys     []sttsEntry
+}
+
+type sttsEntry struct {
+	sampleCount uint32
+	sampleDelta uint32
+}
+
+func newSTTS(b *Box) *stts {
+	return &stts{
+		Box: b,
+	}
+}
+
+//scan stts data in r , return an error ,if any
+func (b *stts) scan(r io.ReaderAt) (err error) {
+	data, err := readTable(r, b.Box, 8)
+	if err != nil {
+		return
+	}
+
+	b.entryCount = uint32(len(data) / 8)
+	b.entrys = make([]sttsEntry, 0, b.entryCount)
+	for i := 0; i < len(data); i += 8 {
+		b.entrys = append(b.entrys, sttsEntry{
+			sampleCount: binary.BigEndian.Uint32(data[i : i+4]),
+			sampleDelta: binary.BigEndian.Uint32(data[i+4 : i+8]),
+		})
+	}
+
+	return
+}
+
+//ctts composition time to sample
+/**
+*header			normalHeaderSize/largeHeaderSize
+*version		1	//offsets are signed in version 1
+*flags			3
+*entryCount		4
+*for each entry
+*	sampleCount		4
+*	sampleOffset	4	//composition time - decoding time
+ */
+type ctts struct {
+	*Box
+	entryCount uint32
+	entrys     []cttsEntry
+}
+
+type cttsEntry struct {
+	sampleCount  uint32
+	sampleOffset int64
+}
+
+func newCTTS(b *Box) *ctts {
+	return &ctts{
+		Box: b,
+	}
+}
+
+//scan ctts data in r , return an error ,if any
+func (b *ctts) scan(r io.ReaderAt) (err error) {
+	version := new([1]byte)
+	if err = readAt(r, version[:], b.offset+int64(b.headerSize)); err != nil {
+		return
+	}
+	if version[0] > 1 {
+		return ErrUnsupportedVersion
+	}
+
+	data, err := readTable(r, b.Box, 8)
+	if err != nil {
+		return
+	}
+
+	b.entryCount = uint32(len(data) / 8)
+	b.entrys = make([]cttsEntry, 0, b.entryCount)
+	for i := 0; i < len(data); i += 8 {
+		offset := int64(binary.BigEndian.Uint32(data[i+4 : i+8]))
+		if version[0] == 1 {
+			offset = int64(int32(offset))
 		}
-		b.sampleSize = append(b.sampleSize, binary.BigEndian.Uint32(temp[:4]))
+		b.entrys = append(b.entrys, cttsEntry{
+			sampleCount:  binary.BigEndian.Uint32(data[i : i+4]),
+			sampleOffset: offset,
+		})
+	}
+
+	return
+}
 
+//stss sync sample , samples not listed are not sync samples ; every sample is sync sample without stss
+/**
+*header			normalHeaderSize/largeHeaderSize
+*version		1
+*flags			3
+*entryCount		4
+*sampleNumber	entryCount*4	//1-based , in increasing order
+ */
+type stss struct {
+	*Box
+	entryCount   uint32
+	sampleNumber []uint32
+}
+
+func newSTSS(b *Box) *stss {
+	return &stss{
+		Box: b,
+	}
+}
+
+//scan stss data in r , return an error ,if any
+func (b *stss) scan(r io.ReaderAt) (err error) {
+	data, err := readTable(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+
+	b.entryCount = uint32(len(data) / 4)
+	b.sampleNumber = make([]uint32, 0, b.entryCount)
+	for i := 0; i < len(data); i += 4 {
+		b.sampleNumber = append(b.sampleNumber, binary.BigEndian.Uint32(data[i:i+4]))
+	}
+
+	return
+}
+
+//sdtp independent and disposable samples
+/**
+*header			normalHeaderSize/largeHeaderSize
+*version		1
+*flags			3
+*for each sample , count is sample count of stsz
+*	is_leading				2 bits
+*	sample_depends_on		2 bits	//1 if depends on others , 2 if not , e.g. I frame
+*	sample_is_depended_on	2 bits	//2 if disposable
+*	sample_has_redundancy	2 bits
+ */
+type sdtp struct {
+	*Box
+	flags []byte
+}
+
+func newSDTP(b *Box) *sdtp {
+	return &sdtp{
+		Box: b,
+	}
+}
+
+//scan sdtp data in r , return an error ,if any
+func (b *sdtp) scan(r io.ReaderAt) (err error) {
+	if b.dataSize() < 4 {
+		return ErrTruncated
+	}
+
+	b.flags = make([]byte, b.dataSize()-4)
+	return readAt(r, b.flags, b.offset+int64(b.headerSize)+4)
+}
+
+//elst edit list , maps presentation timeline of track to media timeline
+/**
+*header				normalHeaderSize/largeHeaderSize
+*version			1
+*flags				3
+*entryCount			4
+*for each entry
+*	segmentDuration		4	//8 if version == 1 , in time scale of movie
+*	mediaTime			4	//8 if version == 1 , signed , in time scale of media , -1 for empty edit
+*	mediaRate			4	//[16.16] signed , 0 for dwell
+ */
+type elst struct {
+	*Box
+	version    uint8
+	entryCount uint32
+	entrys     []elstEntry
+}
+
+type elstEntry struct {
+	segmentDuration uint64
+	mediaTime       int64
+	mediaRate       float64
+}
+
+func newELST(b *Box) *elst {
+	return &elst{
+		Box: b,
+	}
+}
+
+//scan elst data in r , return an error ,if any
+func (b *elst) scan(r io.ReaderAt) (err error) {
+	version := new([1]byte)
+	if err = readAt(r, version[:], b.offset+int64(b.headerSize)); err != nil {
+		return
+	}
+
+	entrySize := 12
+	switch b.version = version[0]; b.version {
+	case 0:
+	case 1:
+		entrySize = 20
+	default:
+		return ErrUnsupportedVersion
+	}
+
+	data, err := readTable(r, b.Box, entrySize)
+	if err != nil {
+		return
+	}
+
+	b.entryCount = uint32(len(data) / entrySize)
+	b.entrys = make([]elstEntry, 0, b.entryCount)
+	for i := 0; i < len(data); i += entrySize {
+		entry := elstEntry{
+			mediaRate: float64(int32(binary.BigEndian.Uint32(data[i+entrySize-4:i+entrySize]))) / (1 << 16),
+		}
+		if b.version == 1 {
+			entry.segmentDuration = binary.BigEndian.Uint64(data[i : i+8])
+			entry.mediaTime = int64(binary.BigEndian.Uint64(data[i+8 : i+16]))
+		} else {
+			entry.segmentDuration = uint64(binary.BigEndian.Uint32(data[i : i+4]))
+			entry.mediaTime = int64(int32(binary.BigEndian.Uint32(data[i+4 : i+8])))
+		}
+		b.entrys = append(b.entrys, entry)
 	}
 
 	return
 }
+
+//readTable return entries of entrySize bytes , following version , flags and entryCount of full box b in r ,
+//	return an error ,if any
+func readTable(r io.ReaderAt, b *Box, entrySize int) ([]byte, error) {
+	temp := new([4]byte)
+	if err := readAt(r, temp[:], b.offset+int64(b.headerSize)+4); err != nil { //read entry count
+		return nil, err
+	}
+
+	entryCount := binary.BigEndian.Uint32(temp[:])
+	if int64(entryCount)*int64(entrySize) > b.dataSize()-8 {
+		return nil, ErrTruncated
+	}
+
+	data := make([]byte, int(entryCount)*entrySize)
+	if err := readAt(r, data, b.offset+int64(b.headerSize)+8); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -1,6 +1,8 @@
 package mp4parser
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 )
 
@@ -24,8 +26,18 @@ func TestIsContainer(t *testing.T) {
 		{"minf", true},
 		{"dinf", true},
 		{"stbl", true},
-		{"stsd", false},
+		{"stsd", true},
+		{"dref", true},
+		{"edts", true},
+		{"udta", true},
+		{"meta", true},
+		{"moof", true},
+		{"traf", true},
+		{"ipco", true},
 		{"fytp", false},
+		{"moo", false},
+		{"oov ", false},
+		{"stts", false},
 		{"", false},
 		{" ", false},
 	}
@@ -38,3 +50,77 @@ func TestIsContainer(t *testing.T) {
 	}
 
 }
+
+//mkBox return a box of boxType with data , used to build test files
+func mkBox(boxType string, data ...[]byte) []byte {
+	payload := bytes.Join(data, nil)
+	b := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(b[:4], uint32(8+len(payload)))
+	copy(b[4:8], boxType)
+	return append(b, payload...)
+}
+
+//mkFullBox return a full box of boxType with version and flags
+func mkFullBox(boxType string, version uint8, flags uint32, data ...[]byte) []byte {
+	vf := []byte{version, byte(flags >> 16), byte(flags >> 8), byte(flags)}
+	return mkBox(boxType, append([][]byte{vf}, data...)...)
+}
+
+//be return big endian bytes of values , which should be fixed size integers
+func be(values ...interface{}) []byte {
+	buffer := new(bytes.Buffer)
+	for _, v := range values {
+		binary.Write(buffer, binary.BigEndian, v)
+	}
+	return buffer.Bytes()
+}
+
+func TestBoxPath(t *testing.T) {
+	root := newBox()
+	moov := newBox()
+	moov.boxType = "moov"
+	root.addInnerBox(moov)
+
+	traks := []*Box{newBox(), newBox()}
+	for _, trak := range traks {
+		trak.boxType = "trak"
+		moov.addInnerBox(trak)
+	}
+	hdlr := newBox()
+	hdlr.boxType = "hdlr"
+	traks[1].addInnerBox(hdlr)
+
+	tests := [...]struct {
+		b    *Box
+		want string
+	}{
+		{root, ""},
+		{moov, "moov"},
+		{traks[0], "moov/trak[0]"},
+		{hdlr, "moov/trak[1]/hdlr"},
+	}
+	for _, test := range tests {
+		if got := test.b.path(); got != test.want {
+			t.Errorf("want %q , got %q", test.want, got)
+		}
+	}
+}
+
+func TestAddInnerBox(t *testing.T) {
+	b := newBox()
+	types := []string{"mvhd", "trak", "iods", "trak", "udta"}
+	for _, boxType := range types {
+		inner := newBox()
+		inner.boxType = boxType
+		b.addInnerBox(inner)
+	}
+
+	for i, inner := range b.innerBoxs {
+		if inner.boxType != types[i] || inner.parent != b {
+			t.Errorf("%d-th inner box,want %s , got %s", i, types[i], inner.boxType)
+		}
+	}
+	if traks := b.typeIndex["trak"]; len(traks) != 2 || traks[0] != b.innerBoxs[1] || traks[1] != b.innerBoxs[3] {
+		t.Errorf("type index of trak,got %v", traks)
+	}
+}
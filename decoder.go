@@ -0,0 +1,97 @@
+package mp4parser
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+//DecoderFunc decodes data of box b from r , return the payload attached to b , see Box.Payload
+//	r reads the whole file , data of b lies in [b.Offset()+b.HeaderSize(), b.Offset()+b.Size())
+type DecoderFunc func(b *Box, r io.ReaderAt) (interface{}, error)
+
+var (
+	decodersMu   sync.RWMutex
+	decoders     = make(map[string]DecoderFunc)
+	uuidDecoders = make(map[[16]byte]DecoderFunc)
+)
+
+func init() {
+	registerDataBox("mvhd", func(b *Box) dataBox { return newMVHD(b) })
+	registerDataBox("tkhd", func(b *Box) dataBox { return newTKHD(b) })
+	registerDataBox("mdhd", func(b *Box) dataBox { return newMDHD(b) })
+	registerDataBox("hdlr", func(b *Box) dataBox { return newHDLR(b) })
+	registerDataBox("stsc", func(b *Box) dataBox { return newSTSC(b) })
+	registerDataBox("stco", func(b *Box) dataBox { return newSTCO(b) })
+	registerDataBox("stsz", func(b *Box) dataBox { return newSTSZ(b) })
+	registerDataBox("stz2", func(b *Box) dataBox { return newSTSZ(b) })
+	registerDataBox("co64", func(b *Box) dataBox { return newSTCO(b) })
+	registerDataBox("stts", func(b *Box) dataBox { return newSTTS(b) })
+	registerDataBox("ctts", func(b *Box) dataBox { return newCTTS(b) })
+	registerDataBox("stss", func(b *Box) dataBox { return newSTSS(b) })
+	registerDataBox("sdtp", func(b *Box) dataBox { return newSDTP(b) })
+	registerDataBox("elst", func(b *Box) dataBox { return newELST(b) })
+	registerDataBox("mehd", func(b *Box) dataBox { return newMEHD(b) })
+	registerDataBox("trex", func(b *Box) dataBox { return newTREX(b) })
+	registerDataBox("mfhd", func(b *Box) dataBox { return newMFHD(b) })
+	registerDataBox("tfhd", func(b *Box) dataBox { return newTFHD(b) })
+	registerDataBox("tfdt", func(b *Box) dataBox { return newTFDT(b) })
+	registerDataBox("trun", func(b *Box) dataBox { return newTRUN(b) })
+}
+
+//RegisterDecoder registers decode for boxes of boxType , a four-character code ,
+//	it panics if decode is nil or boxType already has a decoder , including the built-in ones
+func RegisterDecoder(boxType string, decode DecoderFunc) {
+	if len(boxType) != 4 || boxType == "uuid" {
+		panic(fmt.Sprintf("mp4parser: RegisterDecoder: invalid box type %q", boxType))
+	}
+	if decode == nil {
+		panic(fmt.Sprintf("mp4parser: RegisterDecoder: nil decoder for %q", boxType))
+	}
+
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	if _, dup := decoders[boxType]; dup {
+		panic(fmt.Sprintf("mp4parser: RegisterDecoder: called twice for %q", boxType))
+	}
+	decoders[boxType] = decode
+}
+
+//RegisterUUIDDecoder registers decode for "uuid" boxes of extended type userType ,
+//	it panics if decode is nil or userType already has a decoder
+func RegisterUUIDDecoder(userType [16]byte, decode DecoderFunc) {
+	if decode == nil {
+		panic(fmt.Sprintf("mp4parser: RegisterUUIDDecoder: nil decoder for %x", userType))
+	}
+
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	if _, dup := uuidDecoders[userType]; dup {
+		panic(fmt.Sprintf("mp4parser: RegisterUUIDDecoder: called twice for %x", userType))
+	}
+	uuidDecoders[userType] = decode
+}
+
+//registerDataBox registers built-in dataBox created by newDataBox for boxType
+func registerDataBox(boxType string, newDataBox func(*Box) dataBox) {
+	RegisterDecoder(boxType, func(b *Box, r io.ReaderAt) (interface{}, error) {
+		d := newDataBox(b)
+		if err := d.scan(r); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
+}
+
+//lookupDecoder return the decoder registered for b , nil if there is not any
+func lookupDecoder(b *Box) DecoderFunc {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	if b.boxType == "uuid" {
+		return uuidDecoders[b.userType]
+	}
+	return decoders[b.boxType]
+}
@@ -0,0 +1,112 @@
+package mp4parser
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+var (
+	testUserType = [16]byte{0xbe, 0x7a, 0xcf, 0xcb, 0x97, 0xa9, 0x42, 0xe8, 0x9c, 0x71, 0x99, 0x94, 0x91, 0xe3, 0xaf, 0xac}
+	registerOnce sync.Once
+)
+
+//registerTestDecoders registers decoders reading box data as string
+func registerTestDecoders() {
+	registerOnce.Do(func() {
+		decodeString := func(b *Box, r io.ReaderAt) (interface{}, error) {
+			data := make([]byte, b.Size()-uint64(b.HeaderSize()))
+			if err := readAt(r, data, b.Offset()+int64(b.HeaderSize())); err != nil {
+				return nil, err
+			}
+			if string(data) == "bad" {
+				return nil, ErrInvalidData
+			}
+			return string(data), nil
+		}
+		RegisterDecoder("xtra", decodeString)
+		RegisterUUIDDecoder(testUserType, decodeString)
+	})
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	registerTestDecoders()
+
+	data := bytes.Join([][]byte{
+		mkBox("ftyp", []byte("isom"), make([]byte, 4)),
+		mkBox("xtra", []byte("hello")),
+		mkBox("uuid", testUserType[:], []byte("world")),
+		mkBox("uuid", make([]byte, 16), []byte("unknown")),
+		mkBox("moov", mkMVHD(1000, 1000), mkBox("xtra", []byte("inner"))),
+	}, nil)
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		path string
+		want interface{}
+	}{
+		{"xtra", "hello"},
+		{"uuid[0]", "world"},
+		{"uuid[1]", nil},
+		{"moov/xtra", "inner"},
+		{"ftyp", nil},
+	}
+	for _, test := range tests {
+		boxs, err := p.Query(test.path)
+		if err != nil || len(boxs) != 1 {
+			t.Fatalf("Query(%q),got %v , %v", test.path, boxs, err)
+		}
+		if got := boxs[0].Payload(); got != test.want {
+			t.Errorf("%s payload,want %v , got %v", test.path, test.want, got)
+		}
+	}
+
+	uuidBox, _ := p.Query("uuid[0]")
+	if uuidBox[0].UserType() != testUserType || uuidBox[0].HeaderSize() != normalHeaderSize+16 {
+		t.Errorf("uuid box,got user type %x , header size %d", uuidBox[0].UserType(), uuidBox[0].HeaderSize())
+	}
+
+	mvhdBox, _ := p.Query("moov/mvhd")
+	if _, ok := mvhdBox[0].Payload().(*mvhd); !ok {
+		t.Errorf("mvhd payload,got %T", mvhdBox[0].Payload())
+	}
+
+	bad := append(data[:len(data):len(data)], mkBox("xtra", []byte("bad"))...)
+	_, err := NewReaderAtParser(bytes.NewReader(bad), -1).Parse()
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrInvalidData) || parseErr.Offset != int64(len(data)) {
+		t.Errorf("want ParseError of decoder , got %v", err)
+	}
+}
+
+func TestRegisterDecoderPanic(t *testing.T) {
+	decode := func(b *Box, r io.ReaderAt) (interface{}, error) { return nil, nil }
+
+	tests := [...]struct {
+		name     string
+		register func()
+	}{
+		{"built-in", func() { RegisterDecoder("mvhd", decode) }},
+		{"invalid type", func() { RegisterDecoder("abc", decode) }},
+		{"uuid", func() { RegisterDecoder("uuid", decode) }},
+		{"nil", func() { RegisterDecoder("nil ", nil) }},
+		{"nil uuid", func() { RegisterUUIDDecoder([16]byte{}, nil) }},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer func() {
+				if p := recover(); p == nil {
+					t.Errorf("%s: want panic", test.name)
+				}
+			}()
+			test.register()
+		}()
+	}
+}
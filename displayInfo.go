@@ -0,0 +1,230 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+)
+
+//ColourInfo colour information box , colr , of type nclx or nclc
+type ColourInfo struct {
+	Type                    string //"nclx" , or "nclc" of QuickTime
+	ColourPrimaries         uint16
+	TransferCharacteristics uint16
+	MatrixCoefficients      uint16
+	FullRange               bool //always false in nclc
+}
+
+//parseColourInfo parses data of colr box , return *ColourInfo , or []byte of ICC profile if type is rICC or prof
+/**
+*colour_type				4	//nclx , nclc , rICC or prof
+*colour_primaries			2	//nclx and nclc
+*transfer_characteristics	2
+*matrix_coefficients		2
+*full_range_flag			1 bit	//nclx only
+*_reserved					7 bits
+*ICC_profile				rest	//rICC and prof
+ */
+func parseColourInfo(data []byte) (interface{}, error) {
+	if len(data) < 4 {
+		return nil, ErrTruncated
+	}
+
+	colourType := string(data[0:4])
+	switch colourType {
+	case "rICC", "prof":
+		return append([]byte(nil), data[4:]...), nil
+	case "nclx", "nclc":
+		size := 10
+		if colourType == "nclx" {
+			size = 11
+		}
+		if len(data) < size {
+			return nil, ErrTruncated
+		}
+		return &ColourInfo{
+			Type:                    colourType,
+			ColourPrimaries:         binary.BigEndian.Uint16(data[4:6]),
+			TransferCharacteristics: binary.BigEndian.Uint16(data[6:8]),
+			MatrixCoefficients:      binary.BigEndian.Uint16(data[8:10]),
+			FullRange:               colourType == "nclx" && data[10]&0x80 != 0,
+		}, nil
+	}
+
+	return nil, nil //unknown colour type , data is kept only
+}
+
+//HDR reports whether transfer characteristics is PQ or HLG
+func (c *ColourInfo) HDR() bool {
+	return c.TransferCharacteristics == transferPQ || c.TransferCharacteristics == transferHLG
+}
+
+//PixelAspectRatio pixel aspect ratio box , pasp
+type PixelAspectRatio struct {
+	HSpacing uint32
+	VSpacing uint32
+}
+
+//parsePixelAspectRatio parses data of pasp box
+/**
+*hSpacing	4
+*vSpacing	4
+ */
+func parsePixelAspectRatio(data []byte) (interface{}, error) {
+	if len(data) < 8 {
+		return nil, ErrTruncated
+	}
+	return &PixelAspectRatio{
+		HSpacing: binary.BigEndian.Uint32(data[0:4]),
+		VSpacing: binary.BigEndian.Uint32(data[4:8]),
+	}, nil
+}
+
+//CleanAperture clean aperture box , clap , each value is a fraction of N/D
+type CleanAperture struct {
+	WidthN, WidthD   uint32
+	HeightN, HeightD uint32
+	HorizOffN        int32 //offset of center from center of the picture
+	HorizOffD        uint32
+	VertOffN         int32
+	VertOffD         uint32
+}
+
+//Width return width of clean aperture in pixels , 0 if the denominator is 0
+func (c *CleanAperture) Width() float64 {
+	if c.WidthD == 0 {
+		return 0
+	}
+	return float64(c.WidthN) / float64(c.WidthD)
+}
+
+//Height return height of clean aperture in pixels , 0 if the denominator is 0
+func (c *CleanAperture) Height() float64 {
+	if c.HeightD == 0 {
+		return 0
+	}
+	return float64(c.HeightN) / float64(c.HeightD)
+}
+
+//parseCleanAperture parses data of clap box
+/**
+*cleanApertureWidthN		4
+*cleanApertureWidthD		4
+*cleanApertureHeightN		4
+*cleanApertureHeightD		4
+*horizOffN					4	//signed
+*horizOffD					4
+*vertOffN					4	//signed
+*vertOffD					4
+ */
+func parseCleanAperture(data []byte) (interface{}, error) {
+	if len(data) < 32 {
+		return nil, ErrTruncated
+	}
+	return &CleanAperture{
+		WidthN:    binary.BigEndian.Uint32(data[0:4]),
+		WidthD:    binary.BigEndian.Uint32(data[4:8]),
+		HeightN:   binary.BigEndian.Uint32(data[8:12]),
+		HeightD:   binary.BigEndian.Uint32(data[12:16]),
+		HorizOffN: int32(binary.BigEndian.Uint32(data[16:20])),
+		HorizOffD: binary.BigEndian.Uint32(data[20:24]),
+		VertOffN:  int32(binary.BigEndian.Uint32(data[24:28])),
+		VertOffD:  binary.BigEndian.Uint32(data[28:32]),
+	}, nil
+}
+
+//MasteringDisplay mastering display colour volume box , mdcv , as SMPTE ST 2086
+type MasteringDisplay struct {
+	Primaries    [3][2]uint16 //x , y of green , blue and red , in 0.00002 units
+	WhitePoint   [2]uint16    //x , y in 0.00002 units
+	MaxLuminance uint32       //in 0.0001 cd/m2
+	MinLuminance uint32       //in 0.0001 cd/m2
+}
+
+//parseMasteringDisplay parses data of mdcv box
+/**
+*for green , blue and red
+*	display_primaries_x				2
+*	display_primaries_y				2
+*white_point_x						2
+*white_point_y						2
+*max_display_mastering_luminance	4
+*min_display_mastering_luminance	4
+ */
+func parseMasteringDisplay(data []byte) (interface{}, error) {
+	if len(data) < 24 {
+		return nil, ErrTruncated
+	}
+
+	m := &MasteringDisplay{
+		MaxLuminance: binary.BigEndian.Uint32(data[16:20]),
+		MinLuminance: binary.BigEndian.Uint32(data[20:24]),
+	}
+	for i := range m.Primaries {
+		m.Primaries[i][0] = binary.BigEndian.Uint16(data[i*4 : i*4+2])
+		m.Primaries[i][1] = binary.BigEndian.Uint16(data[i*4+2 : i*4+4])
+	}
+	m.WhitePoint[0] = binary.BigEndian.Uint16(data[12:14])
+	m.WhitePoint[1] = binary.BigEndian.Uint16(data[14:16])
+	return m, nil
+}
+
+//ContentLightLevel content light level box , clli
+type ContentLightLevel struct {
+	MaxCLL  uint16 //max content light level in cd/m2
+	MaxFALL uint16 //max picture average light level in cd/m2
+}
+
+//parseContentLightLevel parses data of clli box
+/**
+*max_content_light_level		2
+*max_pic_average_light_level	2
+ */
+func parseContentLightLevel(data []byte) (interface{}, error) {
+	if len(data) < 4 {
+		return nil, ErrTruncated
+	}
+	return &ContentLightLevel{
+		MaxCLL:  binary.BigEndian.Uint16(data[0:2]),
+		MaxFALL: binary.BigEndian.Uint16(data[2:4]),
+	}, nil
+}
+
+//DolbyVisionConfig Dolby Vision configuration box , dvcC , dvvC or dvwC
+type DolbyVisionConfig struct {
+	VersionMajor      uint8
+	VersionMinor      uint8
+	Profile           uint8
+	Level             uint8
+	RPUPresent        bool
+	ELPresent         bool //enhancement layer
+	BLPresent         bool //base layer
+	BLCompatibilityID uint8
+}
+
+//parseDolbyVisionConfig parses data of dvcC , dvvC and dvwC box
+/**
+*dv_version_major					1
+*dv_version_minor					1
+*dv_profile							7 bits
+*dv_level							6 bits
+*rpu_present_flag					1 bit
+*el_present_flag					1 bit
+*bl_present_flag					1 bit
+*dv_bl_signal_compatibility_id		4 bits
+*_reserved							28 bits + 4*32 bits
+ */
+func parseDolbyVisionConfig(data []byte) (interface{}, error) {
+	if len(data) < 5 {
+		return nil, ErrTruncated
+	}
+	v := binary.BigEndian.Uint16(data[2:4])
+	return &DolbyVisionConfig{
+		VersionMajor:      data[0],
+		VersionMinor:      data[1],
+		Profile:           uint8(v >> 9),
+		Level:             uint8(v >> 3 & 0x3f),
+		RPUPresent:        v&4 != 0,
+		ELPresent:         v&2 != 0,
+		BLPresent:         v&1 != 0,
+		BLCompatibilityID: data[4] >> 4,
+	}, nil
+}
@@ -0,0 +1,78 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDisplayInfo(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	video := info.Tracks()[0].Video()
+	if video.Colour == nil || *video.Colour != (ColourInfo{Type: "nclc", ColourPrimaries: 1, TransferCharacteristics: 1, MatrixCoefficients: 1}) {
+		t.Errorf("colr,got %+v", video.Colour)
+	}
+	if video.HDR() || video.DisplayAspectRatio() != 1.75 {
+		t.Errorf("want SDR of 560:320 , got %v , %f", video.HDR(), video.DisplayAspectRatio())
+	}
+
+	entry := mkVisualSampleEntry("dvh1", 1920, 1080, "",
+		mkBox("hvcC", mkHVCC(testMain10SPS)),
+		mkBox("colr", []byte("nclx"), be(uint16(9), uint16(16), uint16(9)), []byte{0x80}),
+		mkBox("colr", []byte("prof"), []byte{1, 2, 3}),
+		mkBox("pasp", be(uint32(4), uint32(3))),
+		mkBox("clap", be(uint32(3840), uint32(2), uint32(2160), uint32(1), int32(-2), uint32(1), int32(0), uint32(1))),
+		mkBox("mdcv", be(uint16(8500), uint16(39850), uint16(6550), uint16(2300), uint16(35400), uint16(14600),
+			uint16(15635), uint16(16450), uint32(10000000), uint32(50))),
+		mkBox("clli", be(uint16(1000), uint16(400))),
+		mkBox("dvcC", []byte{1, 0}, be(uint16(5<<9|6<<3|5)), []byte{0}, make([]byte, 20)),
+	)
+	data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide", mkSTSD(entry)))
+	info, err = NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	video = info.Tracks()[0].Video()
+	if c := video.Colour; c == nil || c.Type != "nclx" || !c.FullRange || !c.HDR() {
+		t.Errorf("colr,got %+v", c)
+	}
+	if !bytes.Equal(video.ICCProfile, []byte{1, 2, 3}) {
+		t.Errorf("ICC profile,got %x", video.ICCProfile)
+	}
+	if c := video.CleanAperture; c == nil || c.Width() != 1920 || c.Height() != 2160 || c.HorizOffN != -2 {
+		t.Errorf("clap,got %+v", c)
+	}
+	if m := video.MasteringDisplay; m == nil || m.Primaries[2] != [2]uint16{35400, 14600} ||
+		m.WhitePoint != [2]uint16{15635, 16450} || m.MaxLuminance != 10000000 || m.MinLuminance != 50 {
+		t.Errorf("mdcv,got %+v", m)
+	}
+	if c := video.ContentLightLevel; c == nil || c.MaxCLL != 1000 || c.MaxFALL != 400 {
+		t.Errorf("clli,got %+v", c)
+	}
+	want := DolbyVisionConfig{VersionMajor: 1, Profile: 5, Level: 6, RPUPresent: true, BLPresent: true}
+	if video.DolbyVision == nil || *video.DolbyVision != want {
+		t.Errorf("dvcC,want %+v , got %+v", want, video.DolbyVision)
+	}
+	if got := video.CodecString(); got != "dvh1.05.06" || !video.HDR() {
+		t.Errorf("want dvh1.05.06 of HDR , got %s , %v", got, video.HDR())
+	}
+	//1920x2160 of clean aperture , 4:3 pixels
+	if got := video.DisplayAspectRatio(); got < 1.1851 || got > 1.1852 {
+		t.Errorf("DisplayAspectRatio,got %f", got)
+	}
+
+	for name, parse := range map[string]func([]byte) (interface{}, error){
+		"colr": parseColourInfo, "pasp": parsePixelAspectRatio, "clap": parseCleanAperture,
+		"mdcv": parseMasteringDisplay, "clli": parseContentLightLevel, "dvcC": parseDolbyVisionConfig,
+	} {
+		if _, err := parse([]byte{'n', 'c', 'l', 'x'}[:3]); err != ErrTruncated {
+			t.Errorf("%s: want ErrTruncated , got %v", name, err)
+		}
+	}
+	if got, err := parseColourInfo([]byte("nclx\x00\x01")); err != ErrTruncated {
+		t.Errorf("short nclx,got %v , %v", got, err)
+	}
+}
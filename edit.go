@@ -0,0 +1,106 @@
+package mp4parser
+
+import (
+	"time"
+)
+
+//Edit an entry of edit list , elst , mapping a segment of presentation timeline to media of track
+type Edit struct {
+	SegmentDuration uint64  //in time scale of movie , 0 if it lasts to the end of media
+	MediaTime       int64   //start of segment in time scale of media , -1 for empty edit
+	MediaRate       float64 //1 for normal play , 0 for dwell
+}
+
+//Empty reports whether e is an empty edit , nothing is presented during it
+func (e *Edit) Empty() bool {
+	return e.MediaTime == -1
+}
+
+//Edits return entries of edit list of t , nil if t has no edit list
+func (t *Track) Edits() []Edit {
+	elstBox, err := t.box.findBox("edts", "elst")
+	if err != nil {
+		return nil
+	}
+	elstData, ok := elstBox.payload.(*elst)
+	if !ok {
+		return nil
+	}
+
+	edits := make([]Edit, 0, len(elstData.entrys))
+	for _, entry := range elstData.entrys {
+		edits = append(edits, Edit{
+			SegmentDuration: entry.segmentDuration,
+			MediaTime:       entry.mediaTime,
+			MediaRate:       entry.mediaRate,
+		})
+	}
+	return edits
+}
+
+//movieTimeScale return time scale of mvhd , in which segment durations of edits are , time scale of media if not found
+func (t *Track) movieTimeScale() uint32 {
+	if t.box.parent != nil {
+		if mvhdBox, err := t.box.parent.findBox("mvhd"); err == nil {
+			if mvhdData, ok := mvhdBox.payload.(*mvhd); ok && mvhdData.timeScale != 0 {
+				return mvhdData.timeScale
+			}
+		}
+	}
+	return t.timeScale
+}
+
+//PresentationStart return time when media of t starts to be presented , i.e. duration of leading empty edits ,
+//	0 if t has no edit list
+func (t *Track) PresentationStart() time.Duration {
+	start := uint64(0)
+	for _, edit := range t.Edits() {
+		if !edit.Empty() {
+			break
+		}
+		start += edit.SegmentDuration
+	}
+	return toDuration(start, t.movieTimeScale())
+}
+
+//PrimingDelay return media time skipped before the presentation by the first non-empty edit ,
+//	e.g. encoder priming of audio , or composition delay of video with B frames ,
+//	pre-skip of Opus if t has no edit list
+func (t *Track) PrimingDelay() time.Duration {
+	edits := t.Edits()
+	for _, edit := range edits {
+		if !edit.Empty() {
+			if edit.MediaTime < 0 {
+				return 0
+			}
+			return toDuration(uint64(edit.MediaTime), t.timeScale)
+		}
+	}
+	if edits == nil && t.audio != nil && t.audio.Opus != nil {
+		return toDuration(uint64(t.audio.Opus.PreSkip), opusGranuleRate)
+	}
+	return 0
+}
+
+//PresentationDuration return duration of t on timeline of movie , with edit list applied ,
+//	Duration of media if t has no edit list
+func (t *Track) PresentationDuration() time.Duration {
+	edits := t.Edits()
+	if len(edits) == 0 {
+		return t.Duration()
+	}
+
+	total := uint64(0)
+	rest := time.Duration(0) //of the last edit lasts to the end of media
+	for i, edit := range edits {
+		total += edit.SegmentDuration
+		if i == len(edits)-1 && edit.SegmentDuration == 0 && !edit.Empty() && edit.MediaRate != 0 &&
+			edit.MediaTime < int64(t.rawDuration) {
+			rest = toDuration(uint64(int64(t.rawDuration)-edit.MediaTime), t.timeScale)
+			if edit.MediaRate != 1 {
+				rest = time.Duration(float64(rest) / edit.MediaRate)
+			}
+		}
+	}
+	return toDuration(total, t.movieTimeScale()) + rest
+}
@@ -0,0 +1,68 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEdits(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, track := range info.Tracks() {
+		if track.Edits() != nil || track.PresentationStart() != 0 || track.PrimingDelay() != 0 ||
+			track.PresentationDuration() != track.Duration() {
+			t.Errorf("track %d,got edits %v , start %v , priming %v , duration %v",
+				track.ID(), track.Edits(), track.PresentationStart(), track.PrimingDelay(), track.PresentationDuration())
+		}
+	}
+
+	withEdits := func(trak []byte, elstBox []byte) []byte {
+		return mkBox("trak", trak[8:], mkBox("edts", elstBox))
+	}
+	//500ms empty edit , then AAC after 1024 samples of priming to the end of media
+	audio := withEdits(mkTrak(1, "soun"), mkFullBox("elst", 1, 0, be(uint32(2),
+		uint64(500), int64(-1), uint32(1<<16),
+		uint64(0), int64(1024), uint32(1<<16))))
+	video := withEdits(mkTrak(2, "vide"), mkFullBox("elst", 0, 0, be(uint32(1), uint32(1000), uint32(2000), uint32(1<<16))))
+	data := mkBox("moov", mkMVHD(1000, 1000), audio, video)
+	info, err = NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		edits    []Edit
+		start    time.Duration
+		priming  time.Duration
+		duration time.Duration
+	}{
+		{[]Edit{{500, -1, 1}, {0, 1024, 1}}, 500 * time.Millisecond, 21333333, 1478666666},
+		{[]Edit{{1000, 2000, 1}}, 0, 41666666, time.Second},
+	}
+	for i, test := range tests {
+		track := info.Tracks()[i]
+		edits := track.Edits()
+		if len(edits) != len(test.edits) || track.PresentationStart() != test.start ||
+			track.PrimingDelay() != test.priming || track.PresentationDuration() != test.duration {
+			t.Errorf("track %d,want %+v\ngot edits %v , start %v , priming %v , duration %v", track.ID(), test,
+				edits, track.PresentationStart(), track.PrimingDelay(), track.PresentationDuration())
+			continue
+		}
+		for j := range edits {
+			if edits[j] != test.edits[j] {
+				t.Errorf("track %d,edit %d,want %+v , got %+v", track.ID(), j, test.edits[j], edits[j])
+			}
+		}
+	}
+	if *info.Duration() != 1478666666 || info.RawDuration() != 1000 {
+		t.Errorf("want duration of the longest track 1.478666666s , got %v (%d/%d)", info.Duration(), info.RawDuration(), info.TimeScale())
+	}
+
+	opus := &Track{box: newBox(), timeScale: 48000, audio: &AudioInfo{Opus: &OpusConfig{PreSkip: 312}}}
+	if got := opus.PrimingDelay(); got != 6500*time.Microsecond {
+		t.Errorf("Opus without edit list,want priming 6.5ms , got %v", got)
+	}
+}
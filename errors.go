@@ -0,0 +1,61 @@
+package mp4parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+//causes of ParseError , check them by errors.Is
+var (
+	ErrTruncated          = errors.New("truncated data")
+	ErrBadSize            = errors.New("bad box size")
+	ErrUnsupportedVersion = errors.New("unsupported box version")
+	ErrMissingBox         = errors.New("missing box")
+	ErrInvalidData        = errors.New("invalid data")
+)
+
+//ErrNoSyncSample is returned by Track.Seek if the track has no sync sample
+var ErrNoSyncSample = errors.New("no sync sample")
+
+//ParseError describes where and why parsing failed
+type ParseError struct {
+	Path    string //path of box , e.g. moov/trak[1]/mdia/hdlr
+	Offset  int64  //absolute offset of box in file
+	BoxType string
+	Err     error //cause , one of ErrXXX or error from reader
+}
+
+//newParseError return new ParseError of b caused by err , err is returned if it is already a ParseError
+func newParseError(b *Box, err error) error {
+	if _, ok := err.(*ParseError); ok {
+		return err
+	}
+	return &ParseError{
+		Path:    b.path(),
+		Offset:  b.offset,
+		BoxType: b.boxType,
+		Err:     err,
+	}
+}
+
+//isMalformed reports whether err is caused by malformed data
+func isMalformed(err error) bool {
+	return errors.Is(err, ErrTruncated) ||
+		errors.Is(err, ErrBadSize) ||
+		errors.Is(err, ErrUnsupportedVersion) ||
+		errors.Is(err, ErrMissingBox) ||
+		errors.Is(err, ErrInvalidData)
+}
+
+func (e *ParseError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("mp4parser: %s at offset %d: %v", path, e.Offset, e.Err)
+}
+
+//Unwrap return the cause of e
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,84 @@
+package mp4parser
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	mvhd := mkMVHD(1000, 1000)
+	trak := mkTrak(1, "vide")
+	ftyp := mkBox("ftyp", []byte("isom"), make([]byte, 4))
+
+	badHDLR := mkHDLR("soun", "")
+	badHDLR[3] += 10 //overruns mdia
+	badTrak := mkBox("trak", mkTKHD(2, 0, 0, 0), mkBox("mdia", mkMDHD(0, 0), badHDLR))
+
+	stcoTrak := mkTrak(1, "vide", mkFullBox("stco", 0, 0, be(uint32(1000))))
+
+	tests := [...]struct {
+		name       string
+		data       []byte
+		wantErr    error
+		wantPath   string
+		wantOffset int64
+	}{
+		{"zero size in box", mkBox("moov", []byte{0, 0, 0, 0, 'f', 'r', 'e', 'e'}), ErrBadSize, "moov/free", 8},
+		{"size smaller than header", append(ftyp, 0, 0, 0, 7, 'f', 'r', 'e', 'e'), ErrBadSize, "free", int64(len(ftyp))},
+		{"truncated header", append(ftyp, 0, 0, 0), ErrTruncated, "", int64(len(ftyp))},
+		{"truncated large size", append(ftyp, 0, 0, 0, 1, 'm', 'd', 'a', 't', 0, 0), ErrTruncated, "mdat", int64(len(ftyp))},
+		{"overrun", append(ftyp, 0, 0, 0, 100, 'm', 'd', 'a', 't'), ErrBadSize, "mdat", int64(len(ftyp))},
+		{"unsupported version", mkBox("moov", mkFullBox("mvhd", 2, 0, make([]byte, 96))), ErrUnsupportedVersion, "moov/mvhd", 8},
+		{"missing box", mkBox("moov", mvhd, mkBox("trak", mkTKHD(1, 0, 0, 0))), ErrMissingBox, "moov/trak", int64(8 + len(mvhd))},
+		{"truncated table", mkBox("moov", mvhd, stcoTrak),
+			ErrTruncated, "moov/trak/mdia/minf/stbl/stco", int64(8+len(mvhd)+len(stcoTrak)) - 16},
+		{"bad box in second track", mkBox("moov", mvhd, trak, badTrak),
+			ErrBadSize, "moov/trak[1]/mdia/hdlr", int64(8 + len(mvhd) + len(trak) + len(badTrak) - len(badHDLR))},
+	}
+
+	for _, test := range tests {
+		_, err := NewReaderAtParser(bytes.NewReader(test.data), -1).Parse()
+		if !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: want %v , got %v", test.name, test.wantErr, err)
+			continue
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: want ParseError , got %T", test.name, err)
+			continue
+		}
+		if parseErr.Path != test.wantPath || parseErr.Offset != test.wantOffset {
+			t.Errorf("%s: want %s at %d , got %s at %d", test.name, test.wantPath, test.wantOffset, parseErr.Path, parseErr.Offset)
+		}
+	}
+}
+
+//TestParseCorrupted ensures parsing malformed data never panics
+func TestParseCorrupted(t *testing.T) {
+	data, err := ioutil.ReadFile(`./sample/sample.mp4`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	moovOffset := bytes.Index(data, []byte("moov")) - 4
+	corrupted := make([]byte, len(data))
+
+	parse := func(d []byte) {
+		defer func() {
+			if p := recover(); p != nil {
+				t.Fatalf("got panic: %v", p)
+			}
+		}()
+		NewReaderAtParser(bytes.NewReader(d), -1).Parse()
+		NewReaderAtParser(bytes.NewReader(d), -1, Lenient()).Parse()
+	}
+
+	for i := moovOffset; i < len(data); i++ {
+		copy(corrupted, data)
+		corrupted[i] ^= 0xff
+		parse(corrupted)
+		parse(data[:i])
+	}
+}
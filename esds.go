@@ -0,0 +1,332 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+//tags of descriptors in esds
+const (
+	esDescrTag            = 0x03
+	decoderConfigDescrTag = 0x04
+	decSpecificInfoTag    = 0x05
+)
+
+//object type indications of audio carrying AudioSpecificConfig
+const (
+	objectTypeMPEG4Audio   = 0x40
+	objectTypeMPEG2AACMain = 0x66
+	objectTypeMPEG2AACLC   = 0x67
+	objectTypeMPEG2AACSSR  = 0x68
+)
+
+//values in AudioSpecificConfig
+const (
+	audioObjectTypeSBR      = 5
+	audioObjectTypePS       = 29
+	audioObjectTypeEscape   = 31
+	samplingFrequencyEscape = 0xf
+	syncExtensionTypeSBR    = 0x2b7
+	syncExtensionTypePS     = 0x548
+)
+
+//mpeg4SampleRates are sample rates indexed by samplingFrequencyIndex
+var mpeg4SampleRates = [...]uint32{96000, 88200, 64000, 48000, 44100, 32000, 24000, 22050, 16000, 12000, 11025, 8000, 7350}
+
+//mpeg4Channels are number of channels indexed by channelConfiguration , 0 is defined in program config element
+var mpeg4Channels = [...]uint16{0, 1, 2, 3, 4, 5, 6, 8, 0, 0, 0, 7, 8, 24, 8}
+
+//ESDescriptor ES descriptor in esds box , with its DecoderConfigDescriptor
+type ESDescriptor struct {
+	ESID                 uint16
+	ObjectTypeIndication uint8 //e.g. 0x40 for MPEG-4 audio
+	StreamType           uint8 //e.g. 0x05 for audio stream
+	BufferSize           uint32
+	MaxBitrate           uint32 //in bits/s
+	AvgBitrate           uint32 //in bits/s , 0 if variable
+
+	DecoderSpecificInfo []byte
+	AudioConfig         *AudioSpecificConfig //parsed DecoderSpecificInfo of AAC , nil if not or it fails to decode
+}
+
+//CodecString return RFC 6381 codec string of sample entry mp4a , e.g. "mp4a.40.2"
+func (d *ESDescriptor) CodecString() string {
+	if d.AudioConfig != nil && d.ObjectTypeIndication == objectTypeMPEG4Audio {
+		return fmt.Sprintf("mp4a.%02X.%d", d.ObjectTypeIndication, d.AudioConfig.ObjectType)
+	}
+	return fmt.Sprintf("mp4a.%02X", d.ObjectTypeIndication)
+}
+
+//AudioSpecificConfig MPEG-4 audio decoder specific info
+type AudioSpecificConfig struct {
+	ObjectType      uint8  //audio object type signalled first , 5 or 29 for explicitly signalled HE-AAC
+	BaseObjectType  uint8  //audio object type of the core coder , e.g. 2 for AAC LC inside HE-AAC
+	SampleRateIndex uint8  //15 if SampleRate is given explicitly
+	SampleRate      uint32 //of the core coder
+	ChannelConfig   uint8
+
+	SBR                 bool   //spectral band replication , HE-AAC
+	PS                  bool   //parametric stereo , HE-AAC v2
+	ExtensionSampleRate uint32 //output sample rate of SBR , 0 if not signalled
+}
+
+//OutputSampleRate return sample rate of decoded audio in Hz
+func (c *AudioSpecificConfig) OutputSampleRate() uint32 {
+	if c.SBR && c.ExtensionSampleRate != 0 {
+		return c.ExtensionSampleRate
+	}
+	return c.SampleRate
+}
+
+//ChannelCount return number of decoded channels , 0 if defined in program config element
+func (c *AudioSpecificConfig) ChannelCount() uint16 {
+	if int(c.ChannelConfig) >= len(mpeg4Channels) {
+		return 0
+	}
+	if c.PS && c.ChannelConfig == 1 {
+		return 2
+	}
+	return mpeg4Channels[c.ChannelConfig]
+}
+
+//parseESDS parses data of esds box , a full box of ES_Descriptor
+/**
+*version				1
+*flags					3
+*ES_Descriptor
+*	tag					1	//0x03
+*	size				1-4	//7 bits in each byte , msb set if more bytes follow
+*	ES_ID				2
+*	streamDependenceFlag	1 bit
+*	URL_Flag			1 bit
+*	OCRstreamFlag		1 bit
+*	streamPriority		5 bits
+*	dependsOn_ES_ID		2	//if streamDependenceFlag
+*	URLlength			1	//if URL_Flag
+*	URLstring			URLlength
+*	OCR_ES_Id			2	//if OCRstreamFlag
+*	DecoderConfigDescriptor
+*		tag				1	//0x04
+*		size			1-4
+*		objectTypeIndication	1
+*		streamType		6 bits
+*		upStream		1 bit
+*		_reserved		1 bit
+*		bufferSizeDB	3
+*		maxBitrate		4
+*		avgBitrate		4
+*		DecoderSpecificInfo
+*			tag			1	//0x05
+*			size		1-4
+*			data		size
+*	SLConfigDescriptor
+ */
+func parseESDS(data []byte) (interface{}, error) {
+	if len(data) < 4 {
+		return nil, ErrTruncated
+	}
+	if data[0] != 0 {
+		return nil, ErrUnsupportedVersion
+	}
+
+	es, err := findDescriptor(data[4:], esDescrTag)
+	if err != nil {
+		return nil, err
+	}
+	if len(es) < 3 {
+		return nil, ErrTruncated
+	}
+
+	d := &ESDescriptor{
+		ESID: binary.BigEndian.Uint16(es[0:2]),
+	}
+	flags := es[2]
+	es = es[3:]
+	if flags&0x80 != 0 { //streamDependenceFlag
+		es = skipBytes(es, 2)
+	}
+	if flags&0x40 != 0 && len(es) > 0 { //URL_Flag
+		es = skipBytes(es, 1+int(es[0]))
+	}
+	if flags&0x20 != 0 { //OCRstreamFlag
+		es = skipBytes(es, 2)
+	}
+
+	config, err := findDescriptor(es, decoderConfigDescrTag)
+	if err != nil {
+		return nil, err
+	}
+	if len(config) < 13 {
+		return nil, ErrTruncated
+	}
+	d.ObjectTypeIndication = config[0]
+	d.StreamType = config[1] >> 2
+	d.BufferSize = uint32(config[2])<<16 | uint32(config[3])<<8 | uint32(config[4])
+	d.MaxBitrate = binary.BigEndian.Uint32(config[5:9])
+	d.AvgBitrate = binary.BigEndian.Uint32(config[9:13])
+
+	info, err := findDescriptor(config[13:], decSpecificInfoTag)
+	if err == ErrMissingBox { //optional
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	d.DecoderSpecificInfo = append([]byte(nil), info...)
+
+	switch d.ObjectTypeIndication {
+	case objectTypeMPEG4Audio, objectTypeMPEG2AACMain, objectTypeMPEG2AACLC, objectTypeMPEG2AACSSR:
+		d.AudioConfig, _ = parseAudioSpecificConfig(info) //descriptor is still usable without it
+	}
+
+	return d, nil
+}
+
+//findDescriptor return data of the first descriptor of tag in data , skipping others ,
+//	return ErrMissingBox if there is not any
+func findDescriptor(data []byte, tag uint8) ([]byte, error) {
+	for len(data) > 0 {
+		t := data[0]
+		size, n := 0, 1
+		for ; n <= 4; n++ { //size in 4 bytes at most
+			if n >= len(data) {
+				return nil, ErrTruncated
+			}
+			size = size<<7 | int(data[n]&0x7f)
+			if data[n]&0x80 == 0 {
+				break
+			}
+		}
+		if n > 4 {
+			return nil, ErrInvalidData
+		}
+
+		data = data[n+1:]
+		if size > len(data) {
+			return nil, ErrTruncated
+		}
+		if t == tag {
+			return data[:size], nil
+		}
+		data = data[size:]
+	}
+	return nil, ErrMissingBox
+}
+
+//skipBytes return data after n bytes , empty if data is shorter
+func skipBytes(data []byte, n int) []byte {
+	if n > len(data) {
+		return data[len(data):]
+	}
+	return data[n:]
+}
+
+//parseAudioSpecificConfig parses AudioSpecificConfig of AAC , following signalling of SBR and PS
+/**
+*audioObjectType			5 bits	//31 , escape to 32 + 6 bits
+*samplingFrequencyIndex		4 bits
+*samplingFrequency			24 bits	//if samplingFrequencyIndex == 0xf
+*channelConfiguration		4 bits
+*							//if audioObjectType is 5 or 29 , explicit hierarchical signalling
+*extensionSamplingFrequencyIndex	4 bits
+*extensionSamplingFrequency	24 bits	//if extensionSamplingFrequencyIndex == 0xf
+*audioObjectType			5 bits	//of the core coder
+*GASpecificConfig				//for AAC object types
+*	frameLengthFlag			1 bit
+*	dependsOnCoreCoder		1 bit
+*	coreCoderDelay			14 bits	//if dependsOnCoreCoder
+*	extensionFlag			1 bit
+*	layerNr					3 bits	//if audioObjectType == 6
+*	extensionFlag3			1 bit	//if extensionFlag
+*							//backward compatible signalling , if more bits left
+*syncExtensionType			11 bits	//0x2b7
+*extensionAudioObjectType	5 bits
+*sbrPresentFlag				1 bit	//if extensionAudioObjectType == 5
+*extensionSamplingFrequencyIndex	4 bits	//if sbrPresentFlag
+*syncExtensionType			11 bits	//0x548
+*psPresentFlag				1 bit
+ */
+func parseAudioSpecificConfig(data []byte) (*AudioSpecificConfig, error) {
+	r := newBitReader(data)
+	c := &AudioSpecificConfig{
+		ObjectType: readAudioObjectType(r),
+	}
+	c.SampleRateIndex, c.SampleRate = readSamplingFrequency(r)
+	c.ChannelConfig = uint8(r.readBits(4))
+	c.BaseObjectType = c.ObjectType
+
+	if c.ObjectType == audioObjectTypeSBR || c.ObjectType == audioObjectTypePS {
+		c.SBR = true
+		c.PS = c.ObjectType == audioObjectTypePS
+		_, c.ExtensionSampleRate = readSamplingFrequency(r)
+		c.BaseObjectType = readAudioObjectType(r)
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	switch c.BaseObjectType {
+	case 1, 2, 3, 4, 6, 7: //GASpecificConfig without error resilience
+		if c.ChannelConfig == 0 { //program config element , not followed
+			return c, nil
+		}
+		r.skip(1) //frameLengthFlag
+		if r.readFlag() {
+			r.skip(14) //coreCoderDelay
+		}
+		extensionFlag := r.readFlag()
+		if c.BaseObjectType == 6 {
+			r.skip(3) //layerNr
+		}
+		if extensionFlag {
+			r.skip(1) //extensionFlag3
+		}
+	default:
+		return c, nil
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if c.SBR || r.bitsLeft() < 16 {
+		return c, nil
+	}
+	if r.readBits(11) != syncExtensionTypeSBR {
+		return c, nil
+	}
+	if readAudioObjectType(r) == audioObjectTypeSBR {
+		if c.SBR = r.readFlag(); c.SBR {
+			_, c.ExtensionSampleRate = readSamplingFrequency(r)
+			if r.bitsLeft() >= 12 && r.readBits(11) == syncExtensionTypePS {
+				c.PS = r.readFlag()
+			}
+		}
+	}
+	if r.err != nil { //trailing signalling is optional
+		c.SBR, c.PS, c.ExtensionSampleRate = false, false, 0
+	}
+
+	return c, nil
+}
+
+//readAudioObjectType reads audioObjectType with escape from r
+func readAudioObjectType(r *bitReader) uint8 {
+	aot := uint8(r.readBits(5))
+	if aot == audioObjectTypeEscape {
+		aot = 32 + uint8(r.readBits(6))
+	}
+	return aot
+}
+
+//readSamplingFrequency reads samplingFrequencyIndex and samplingFrequency if escaped from r ,
+//	return the index and the sample rate , 0 if the index is reserved
+func readSamplingFrequency(r *bitReader) (uint8, uint32) {
+	index := uint8(r.readBits(4))
+	if index == samplingFrequencyEscape {
+		return index, uint32(r.readBits(24))
+	}
+	if int(index) < len(mpeg4SampleRates) {
+		return index, mpeg4SampleRates[index]
+	}
+	return index, 0
+}
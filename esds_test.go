@@ -0,0 +1,99 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+//mkESDS return an esds box of objectTypeIndication and decoder specific info
+func mkESDS(objectType uint8, info []byte) []byte {
+	decSpecificInfo := append([]byte{decSpecificInfoTag, byte(len(info))}, info...)
+	config := bytes.Join([][]byte{
+		{decoderConfigDescrTag, byte(13 + len(decSpecificInfo)), objectType, 0x15, 0, 0x03, 0},
+		be(uint32(128000), uint32(96000)), decSpecificInfo,
+	}, nil)
+	es := bytes.Join([][]byte{
+		{esDescrTag, 0x80, 0x80, 0x80, byte(3 + len(config) + 3)}, be(uint16(1)), {0},
+		config, {0x06, 1, 2},
+	}, nil)
+	return mkFullBox("esds", 0, 0, es)
+}
+
+func TestParseESDS(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	audio := info.Tracks()[1].Audio()
+	if audio.ES == nil || audio.ES.ObjectTypeIndication != 0x40 || audio.ES.StreamType != 5 ||
+		audio.ES.AudioConfig == nil || audio.ES.AudioConfig.ObjectType != 2 {
+		t.Fatalf("got %+v", audio.ES)
+	}
+	if got := info.Tracks()[1].CodecString(); got != "mp4a.40.2" {
+		t.Errorf("CodecString,want mp4a.40.2 , got %s", got)
+	}
+
+	tests := [...]struct {
+		name       string
+		objectType uint8
+		info       []byte
+		codec      string
+		want       AudioSpecificConfig
+	}{
+		{"AAC LC", 0x40, []byte{0x12, 0x10},
+			"mp4a.40.2", AudioSpecificConfig{ObjectType: 2, BaseObjectType: 2, SampleRateIndex: 4, SampleRate: 44100, ChannelConfig: 2}},
+		//AAC LC 24000 Hz , mono , with backward compatible SBR at 48000 Hz and PS
+		{"HE-AAC v2 implicit", 0x40, []byte{0x13, 0x08, 0x56, 0xe5, 0x9d, 0x48, 0x80},
+			"mp4a.40.2", AudioSpecificConfig{ObjectType: 2, BaseObjectType: 2, SampleRateIndex: 6, SampleRate: 24000, ChannelConfig: 1,
+				SBR: true, PS: true, ExtensionSampleRate: 48000}},
+		//SBR , 22050 Hz stereo , extension at 44100 Hz , AAC LC
+		{"HE-AAC explicit", 0x40, []byte{0x2b, 0x92, 0x08, 0x00},
+			"mp4a.40.5", AudioSpecificConfig{ObjectType: 5, BaseObjectType: 2, SampleRateIndex: 7, SampleRate: 22050, ChannelConfig: 2,
+				SBR: true, ExtensionSampleRate: 44100}},
+		{"escaped object type and sample rate", 0x40, []byte{0xf8, 0x1e, 0x00, 0x07, 0xd0, 0x20},
+			"mp4a.40.32", AudioSpecificConfig{ObjectType: 32, BaseObjectType: 32, SampleRateIndex: 15, SampleRate: 1000, ChannelConfig: 1}},
+		{"MPEG-2 AAC LC", 0x67, []byte{0x11, 0x90},
+			"mp4a.67", AudioSpecificConfig{ObjectType: 2, BaseObjectType: 2, SampleRateIndex: 3, SampleRate: 48000, ChannelConfig: 2}},
+	}
+	for _, test := range tests {
+		got, err := parseESDS(mkESDS(test.objectType, test.info)[normalHeaderSize:])
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		d := got.(*ESDescriptor)
+		if d.ESID != 1 || d.MaxBitrate != 128000 || d.AvgBitrate != 96000 || !bytes.Equal(d.DecoderSpecificInfo, test.info) {
+			t.Errorf("%s: got %+v", test.name, d)
+		}
+		if d.AudioConfig == nil || *d.AudioConfig != test.want {
+			t.Errorf("%s: want %+v , got %+v", test.name, test.want, d.AudioConfig)
+		}
+		if d.CodecString() != test.codec {
+			t.Errorf("%s: want %s , got %s", test.name, test.codec, d.CodecString())
+		}
+	}
+
+	mp3, err := parseESDS(mkESDS(0x6b, nil)[normalHeaderSize:])
+	if err != nil || mp3.(*ESDescriptor).AudioConfig != nil || mp3.(*ESDescriptor).CodecString() != "mp4a.6B" {
+		t.Errorf("MP3 , got %+v , %v", mp3, err)
+	}
+	//descriptor is kept if AudioSpecificConfig fails to decode
+	if _, err := parseAudioSpecificConfig([]byte{0x12}); err == nil {
+		t.Errorf("parseAudioSpecificConfig(12),want error")
+	}
+	corrupt, err := parseESDS(mkESDS(0x40, []byte{0x12})[normalHeaderSize:])
+	if d, ok := corrupt.(*ESDescriptor); err != nil || !ok || d.AudioConfig != nil || d.MaxBitrate != 128000 ||
+		d.AvgBitrate != 96000 || d.CodecString() != "mp4a.40" {
+		t.Errorf("corrupt AudioSpecificConfig , got %+v , %v", corrupt, err)
+	}
+
+	for _, data := range [...][]byte{
+		{0, 0, 0, 0, esDescrTag, 10, 0},
+		{0, 0, 0, 0, esDescrTag, 0x80, 0x80, 0x80, 0x80, 0x80},
+		{0, 0, 0, 0, 0x06, 1, 2},
+	} {
+		if _, err := parseESDS(data); err == nil {
+			t.Errorf("parseESDS(%x),want error", data)
+		}
+	}
+}
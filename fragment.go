@@ -0,0 +1,505 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+//flags of tfhd
+const (
+	tfhdBaseDataOffset         = 0x000001
+	tfhdSampleDescriptionIndex = 0x000002
+	tfhdDefaultSampleDuration  = 0x000008
+	tfhdDefaultSampleSize      = 0x000010
+	tfhdDefaultSampleFlags     = 0x000020
+	tfhdDurationIsEmpty        = 0x010000
+	tfhdDefaultBaseIsMoof      = 0x020000
+)
+
+//flags of trun
+const (
+	trunDataOffset            = 0x000001
+	trunFirstSampleFlags      = 0x000004
+	trunSampleDuration        = 0x000100
+	trunSampleSize            = 0x000200
+	trunSampleFlags           = 0x000400
+	trunSampleCompositionTime = 0x000800
+)
+
+//sampleIsNonSync bit of sample flags in trex , tfhd and trun , sample_is_non_sync_sample
+const sampleIsNonSync = 0x00010000
+
+//readFullBox reads all data of full box b in r , return its version , flags and data following them ,
+//	ErrTruncated if data is shorter than minSize following version and flags
+func readFullBox(r io.ReaderAt, b *Box, minSize int) (version uint8, flags uint32, data []byte, err error) {
+	if b.dataSize() < int64(4+minSize) {
+		return 0, 0, nil, ErrTruncated
+	}
+	data = make([]byte, b.dataSize())
+	if err = readAt(r, data, b.offset+int64(b.headerSize)); err != nil {
+		return 0, 0, nil, err
+	}
+	return data[0], binary.BigEndian.Uint32(data[0:4]) & 0xffffff, data[4:], nil
+}
+
+//mehd movie extends header
+/**
+*header				normalHeaderSize/largeHeaderSize
+*version			1
+*flags				3
+*fragmentDuration	4	//8 if version == 1 , in time scale of movie
+ */
+type mehd struct {
+	*Box
+	fragmentDuration uint64
+}
+
+func newMEHD(b *Box) *mehd {
+	return &mehd{
+		Box: b,
+	}
+}
+
+//scan mehd data in r , return an error ,if any
+func (b *mehd) scan(r io.ReaderAt) (err error) {
+	version, _, data, err := readFullBox(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+	b.fragmentDuration, _, err = readVersionedUint(data, version)
+	return
+}
+
+//trex track extends , defaults of samples in fragments of a track
+/**
+*header								normalHeaderSize/largeHeaderSize
+*version							1
+*flags								3
+*trackID							4
+*defaultSampleDescriptionIndex		4
+*defaultSampleDuration				4
+*defaultSampleSize					4
+*defaultSampleFlags					4
+ */
+type trex struct {
+	*Box
+	trackID                       uint32
+	defaultSampleDescriptionIndex uint32
+	defaultSampleDuration         uint32
+	defaultSampleSize             uint32
+	defaultSampleFlags            uint32
+}
+
+func newTREX(b *Box) *trex {
+	return &trex{
+		Box: b,
+	}
+}
+
+//scan trex data in r , return an error ,if any
+func (b *trex) scan(r io.ReaderAt) (err error) {
+	_, _, data, err := readFullBox(r, b.Box, 20)
+	if err != nil {
+		return
+	}
+	b.trackID = binary.BigEndian.Uint32(data[0:4])
+	b.defaultSampleDescriptionIndex = binary.BigEndian.Uint32(data[4:8])
+	b.defaultSampleDuration = binary.BigEndian.Uint32(data[8:12])
+	b.defaultSampleSize = binary.BigEndian.Uint32(data[12:16])
+	b.defaultSampleFlags = binary.BigEndian.Uint32(data[16:20])
+	return
+}
+
+//mfhd movie fragment header
+/**
+*header				normalHeaderSize/largeHeaderSize
+*version			1
+*flags				3
+*sequenceNumber		4
+ */
+type mfhd struct {
+	*Box
+	sequenceNumber uint32
+}
+
+func newMFHD(b *Box) *mfhd {
+	return &mfhd{
+		Box: b,
+	}
+}
+
+//scan mfhd data in r , return an error ,if any
+func (b *mfhd) scan(r io.ReaderAt) (err error) {
+	_, _, data, err := readFullBox(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+	b.sequenceNumber = binary.BigEndian.Uint32(data[0:4])
+	return
+}
+
+//tfhd track fragment header , fields following trackID are present by flags
+/**
+*header							normalHeaderSize/largeHeaderSize
+*version						1
+*flags							3
+*trackID						4
+*baseDataOffset					8	//tfhdBaseDataOffset
+*sampleDescriptionIndex			4	//tfhdSampleDescriptionIndex
+*defaultSampleDuration			4	//tfhdDefaultSampleDuration
+*defaultSampleSize				4	//tfhdDefaultSampleSize
+*defaultSampleFlags				4	//tfhdDefaultSampleFlags
+ */
+type tfhd struct {
+	*Box
+	flags                  uint32
+	trackID                uint32
+	baseDataOffset         uint64
+	sampleDescriptionIndex uint32
+	defaultSampleDuration  uint32
+	defaultSampleSize      uint32
+	defaultSampleFlags     uint32
+}
+
+func newTFHD(b *Box) *tfhd {
+	return &tfhd{
+		Box: b,
+	}
+}
+
+//scan tfhd data in r , return an error ,if any
+func (b *tfhd) scan(r io.ReaderAt) (err error) {
+	_, flags, data, err := readFullBox(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+	b.flags = flags
+	b.trackID = binary.BigEndian.Uint32(data[0:4])
+	data = data[4:]
+
+	fields := [...]struct {
+		flag  uint32
+		value *uint32
+	}{
+		{tfhdSampleDescriptionIndex, &b.sampleDescriptionIndex},
+		{tfhdDefaultSampleDuration, &b.defaultSampleDuration},
+		{tfhdDefaultSampleSize, &b.defaultSampleSize},
+		{tfhdDefaultSampleFlags, &b.defaultSampleFlags},
+	}
+	if flags&tfhdBaseDataOffset != 0 {
+		if len(data) < 8 {
+			return ErrTruncated
+		}
+		b.baseDataOffset = binary.BigEndian.Uint64(data[0:8])
+		data = data[8:]
+	}
+	for _, field := range fields {
+		if flags&field.flag == 0 {
+			continue
+		}
+		if len(data) < 4 {
+			return ErrTruncated
+		}
+		*field.value = binary.BigEndian.Uint32(data[0:4])
+		data = data[4:]
+	}
+
+	return
+}
+
+//tfdt track fragment decode time
+/**
+*header					normalHeaderSize/largeHeaderSize
+*version				1
+*flags					3
+*baseMediaDecodeTime	4	//8 if version == 1 , in time scale of media
+ */
+type tfdt struct {
+	*Box
+	baseMediaDecodeTime uint64
+}
+
+func newTFDT(b *Box) *tfdt {
+	return &tfdt{
+		Box: b,
+	}
+}
+
+//scan tfdt data in r , return an error ,if any
+func (b *tfdt) scan(r io.ReaderAt) (err error) {
+	version, _, data, err := readFullBox(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+	b.baseMediaDecodeTime, _, err = readVersionedUint(data, version)
+	return
+}
+
+//readVersionedUint reads an unsigned integer of 4 bytes in version 0 , 8 bytes in version 1 , return it and data left
+func readVersionedUint(data []byte, version uint8) (uint64, []byte, error) {
+	switch version {
+	case 0:
+		return uint64(binary.BigEndian.Uint32(data[0:4])), data[4:], nil
+	case 1:
+		if len(data) < 8 {
+			return 0, nil, ErrTruncated
+		}
+		return binary.BigEndian.Uint64(data[0:8]), data[8:], nil
+	}
+	return 0, nil, ErrUnsupportedVersion
+}
+
+//trun track fragment run , fields are present by flags
+/**
+*header							normalHeaderSize/largeHeaderSize
+*version						1	//composition time offsets are signed in version 1
+*flags							3
+*sampleCount					4
+*dataOffset						4	//trunDataOffset , signed , from base data offset
+*firstSampleFlags				4	//trunFirstSampleFlags
+*for each sample
+*	sampleDuration				4	//trunSampleDuration
+*	sampleSize					4	//trunSampleSize
+*	sampleFlags					4	//trunSampleFlags
+*	sampleCompositionTimeOffset	4	//trunSampleCompositionTime
+ */
+type trun struct {
+	*Box
+	flags            uint32
+	sampleCount      uint32
+	dataOffset       int32
+	firstSampleFlags uint32
+	entrys           []trunEntry //nil if no field is present per sample , all samples take defaults
+}
+
+type trunEntry struct {
+	sampleDuration              uint32
+	sampleSize                  uint32
+	sampleFlags                 uint32
+	sampleCompositionTimeOffset int64
+}
+
+func newTRUN(b *Box) *trun {
+	return &trun{
+		Box: b,
+	}
+}
+
+//scan trun data in r , return an error ,if any
+func (b *trun) scan(r io.ReaderAt) (err error) {
+	version, flags, data, err := readFullBox(r, b.Box, 4)
+	if err != nil {
+		return
+	}
+	if version > 1 {
+		return ErrUnsupportedVersion
+	}
+	b.flags = flags
+	b.sampleCount = binary.BigEndian.Uint32(data[0:4])
+	data = data[4:]
+
+	for _, flag := range [...]uint32{trunDataOffset, trunFirstSampleFlags} {
+		if flags&flag == 0 {
+			continue
+		}
+		if len(data) < 4 {
+			return ErrTruncated
+		}
+		if flag == trunDataOffset {
+			b.dataOffset = int32(binary.BigEndian.Uint32(data[0:4]))
+		} else {
+			b.firstSampleFlags = binary.BigEndian.Uint32(data[0:4])
+		}
+		data = data[4:]
+	}
+
+	entrySize := 0
+	for _, flag := range [...]uint32{trunSampleDuration, trunSampleSize, trunSampleFlags, trunSampleCompositionTime} {
+		if flags&flag != 0 {
+			entrySize += 4
+		}
+	}
+	if int64(b.sampleCount)*int64(entrySize) > int64(len(data)) {
+		return ErrTruncated
+	}
+
+	if entrySize == 0 { //sampleCount is bounded by data of samples , checked when they are indexed
+		return
+	}
+	b.entrys = make([]trunEntry, b.sampleCount)
+	for i := range b.entrys {
+		entry := &b.entrys[i]
+		if flags&trunSampleDuration != 0 {
+			entry.sampleDuration = binary.BigEndian.Uint32(data[0:4])
+			data = data[4:]
+		}
+		if flags&trunSampleSize != 0 {
+			entry.sampleSize = binary.BigEndian.Uint32(data[0:4])
+			data = data[4:]
+		}
+		if flags&trunSampleFlags != 0 {
+			entry.sampleFlags = binary.BigEndian.Uint32(data[0:4])
+			data = data[4:]
+		}
+		if flags&trunSampleCompositionTime != 0 {
+			entry.sampleCompositionTimeOffset = int64(binary.BigEndian.Uint32(data[0:4]))
+			if version == 1 {
+				entry.sampleCompositionTimeOffset = int64(int32(entry.sampleCompositionTimeOffset))
+			}
+			data = data[4:]
+		}
+	}
+
+	return
+}
+
+//fragmented reports whether movie of t is fragmented , i.e. moov contains mvex
+func (t *Track) fragmented() bool {
+	if t.box.parent == nil {
+		return false
+	}
+	_, err := t.box.parent.findBox("mvex")
+	return err == nil
+}
+
+//fragmentSamples return samples of t in moof boxs , in file order , numbered from number ,
+//	decoding time continues from dts unless tfdt is present , return an error if any box is missing or not decoded
+func (t *Track) fragmentSamples(number uint32, dts int64) ([]Sample, error) {
+	moovBox := t.box.parent
+	if moovBox == nil || moovBox.parent == nil {
+		return nil, nil
+	}
+
+	trexs := make(map[uint32]*trex)
+	if mvexBox, err := moovBox.findBox("mvex"); err == nil {
+		for _, trexBox := range mvexBox.typeIndex["trex"] {
+			if trexData, ok := trexBox.payload.(*trex); ok {
+				trexs[trexData.trackID] = trexData
+			}
+		}
+	}
+
+	fileSize := t.fileSize()
+	claimed := uint64(0) //bytes of samples of default size in all runs , runs may share data by data_offset
+	var samples []Sample
+	for _, moofBox := range moovBox.parent.typeIndex["moof"] {
+		dataEnd := uint64(moofBox.offset) //end of data of the previous traf
+		for i, trafBox := range moofBox.typeIndex["traf"] {
+			tfhdBox, err := trafBox.findBox("tfhd")
+			if err != nil {
+				return nil, err
+			}
+			tfhdData, ok := tfhdBox.payload.(*tfhd)
+			if !ok {
+				return nil, newParseError(tfhdBox, ErrInvalidData)
+			}
+			trackDefaults := trexs[tfhdData.trackID]
+			if trackDefaults == nil {
+				trackDefaults = new(trex)
+			}
+			mine := tfhdData.trackID == t.id
+
+			base := dataEnd
+			switch {
+			case tfhdData.flags&tfhdBaseDataOffset != 0:
+				base = tfhdData.baseDataOffset
+			case i == 0 || tfhdData.flags&tfhdDefaultBaseIsMoof != 0:
+				base = uint64(moofBox.offset)
+			}
+			if mine {
+				if tfdtBox, err := trafBox.findBox("tfdt"); err == nil {
+					if tfdtData, ok := tfdtBox.payload.(*tfdt); ok {
+						dts = int64(tfdtData.baseMediaDecodeTime)
+					}
+				}
+			}
+
+			offset := base
+			for _, trunBox := range trafBox.typeIndex["trun"] {
+				trunData, ok := trunBox.payload.(*trun)
+				if !ok {
+					return nil, newParseError(trunBox, ErrInvalidData)
+				}
+				if trunData.flags&trunDataOffset != 0 {
+					offset = uint64(int64(base) + int64(trunData.dataOffset))
+				}
+
+				if trunData.entrys == nil && trunData.sampleCount > 0 {
+					//samples of default size must fit in file , a sample takes at least a byte
+					size := uint64(fragmentSample(trackDefaults, tfhdData, trunData, 0, &trunEntry{}).Size)
+					if size == 0 {
+						size = 1
+					}
+					if offset > fileSize || uint64(trunData.sampleCount)*size > fileSize-offset {
+						return nil, newParseError(trunBox, fmt.Errorf("%w: %d samples overrun file", ErrInvalidData, trunData.sampleCount))
+					}
+					claimed += uint64(trunData.sampleCount) * size
+					if claimed > fileSize {
+						return nil, newParseError(trunBox, fmt.Errorf("%w: samples of runs overrun file", ErrInvalidData))
+					}
+				}
+
+				for j := uint32(0); j < trunData.sampleCount; j++ {
+					entry := trunEntry{}
+					if trunData.entrys != nil {
+						entry = trunData.entrys[j]
+					}
+					s := fragmentSample(trackDefaults, tfhdData, trunData, int(j), &entry)
+					if mine {
+						s.Number = number
+						s.Offset = int64(offset)
+						s.DTS = dts
+						s.PTS = dts + entry.sampleCompositionTimeOffset
+						samples = append(samples, s)
+						number++
+						dts += int64(s.Duration)
+					}
+					offset += uint64(s.Size)
+				}
+			}
+			dataEnd = offset
+		}
+	}
+	return samples, nil
+}
+
+//fragmentSample return size , duration , sync and description index of jth sample of run ,
+//	fields not in run are taken from tfhd , then trex
+func fragmentSample(defaults *trex, header *tfhd, run *trun, j int, entry *trunEntry) Sample {
+	s := Sample{
+		Size:             defaults.defaultSampleSize,
+		Duration:         defaults.defaultSampleDuration,
+		DescriptionIndex: defaults.defaultSampleDescriptionIndex,
+	}
+	flags := defaults.defaultSampleFlags
+
+	if header.flags&tfhdSampleDescriptionIndex != 0 {
+		s.DescriptionIndex = header.sampleDescriptionIndex
+	}
+	if header.flags&tfhdDefaultSampleDuration != 0 {
+		s.Duration = header.defaultSampleDuration
+	}
+	if header.flags&tfhdDefaultSampleSize != 0 {
+		s.Size = header.defaultSampleSize
+	}
+	if header.flags&tfhdDefaultSampleFlags != 0 {
+		flags = header.defaultSampleFlags
+	}
+
+	if run.flags&trunSampleDuration != 0 {
+		s.Duration = entry.sampleDuration
+	}
+	if run.flags&trunSampleSize != 0 {
+		s.Size = entry.sampleSize
+	}
+	switch {
+	case j == 0 && run.flags&trunFirstSampleFlags != 0:
+		flags = run.firstSampleFlags
+	case run.flags&trunSampleFlags != 0:
+		flags = entry.sampleFlags
+	}
+	s.Sync = flags&sampleIsNonSync == 0
+
+	return s
+}
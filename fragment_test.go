@@ -0,0 +1,194 @@
+package mp4parser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//mkFragmentedTrak return a trak box with empty sample table , as in fragmented movie
+func mkFragmentedTrak(trackID uint32, handlerType string) []byte {
+	return mkBox("trak",
+		mkTKHD(trackID, 0, 0, 0),
+		mkBox("mdia",
+			mkMDHD(48000, 0),
+			mkHDLR(handlerType, ""),
+			mkBox("minf", mkBox("stbl",
+				mkFullBox("stsz", 0, 0, be(uint32(0), uint32(0))),
+				mkFullBox("stsc", 0, 0, be(uint32(0))),
+				mkFullBox("stco", 0, 0, be(uint32(0))),
+				mkFullBox("stts", 0, 0, be(uint32(0)))))))
+}
+
+func TestFragmented(t *testing.T) {
+	moov := mkBox("moov", mkMVHD(1000, 0), mkFragmentedTrak(1, "vide"), mkFragmentedTrak(2, "soun"),
+		mkBox("mvex",
+			mkFullBox("mehd", 1, 0, be(uint64(375))),
+			mkFullBox("trex", 0, 0, be(uint32(1), uint32(1), uint32(3000), uint32(0), uint32(sampleIsNonSync))),
+			mkFullBox("trex", 0, 0, be(uint32(2), uint32(1), uint32(1024), uint32(4), uint32(0)))))
+
+	//video samples are found by data offset from moof , audio follows them
+	moof1 := func(dataOffset int32) []byte {
+		return mkBox("moof",
+			mkFullBox("mfhd", 0, 0, be(uint32(1))),
+			mkBox("traf",
+				mkFullBox("tfhd", 0, tfhdDefaultBaseIsMoof, be(uint32(1))),
+				mkFullBox("tfdt", 1, 0, be(uint64(0))),
+				mkFullBox("trun", 1, trunDataOffset|trunFirstSampleFlags|trunSampleSize|trunSampleCompositionTime,
+					be(uint32(3), dataOffset, uint32(0), uint32(10), int32(6000), uint32(20), int32(0), uint32(30), int32(-3000)))),
+			mkBox("traf",
+				mkFullBox("tfhd", 0, 0, be(uint32(2))),
+				mkFullBox("trun", 0, 0, be(uint32(2)))))
+	}
+	//video samples are found by base data offset , with defaults in tfhd
+	moof2 := func(base uint64) []byte {
+		return mkBox("moof",
+			mkFullBox("mfhd", 0, 0, be(uint32(2))),
+			mkBox("traf",
+				mkFullBox("tfhd", 0, tfhdBaseDataOffset|tfhdSampleDescriptionIndex|tfhdDefaultSampleDuration|tfhdDefaultSampleSize|tfhdDefaultSampleFlags,
+					be(uint32(1), base, uint32(2), uint32(1000), uint32(7), uint32(sampleIsNonSync))),
+				mkFullBox("trun", 0, trunSampleFlags|trunSampleDuration,
+					be(uint32(2), uint32(3000), uint32(0), uint32(6000), uint32(sampleIsNonSync)))))
+	}
+
+	mdat1 := mkBox("mdat", bytes.Repeat([]byte{'v'}, 60), []byte("aaaabbbb"))
+	mdat2 := mkBox("mdat", bytes.Repeat([]byte{'w'}, 14))
+	moof1Offset := int64(len(moov))
+	moof2Offset := moof1Offset + int64(len(moof1(0))+len(mdat1))
+	data := bytes.Join([][]byte{moov, moof1(int32(len(moof1(0)) + 8)), mdat1,
+		moof2(uint64(moof2Offset) + uint64(len(moof2(0))) + 8), mdat2}, nil)
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	info, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	video := moof1Offset + int64(len(moof1(0))) + 8
+	video2 := moof2Offset + int64(len(moof2(0))) + 8
+	tests := [...]struct {
+		samples  []Sample
+		duration time.Duration
+	}{
+		{[]Sample{
+			{1, video, 10, 0, 6000, 3000, true, 1},
+			{2, video + 10, 20, 3000, 3000, 3000, false, 1},
+			{3, video + 30, 30, 6000, 3000, 3000, false, 1},
+			{4, video2, 7, 9000, 9000, 3000, true, 2},
+			{5, video2 + 7, 7, 12000, 12000, 6000, false, 2},
+		}, 375 * time.Millisecond},
+		{[]Sample{
+			{1, video + 60, 4, 0, 0, 1024, true, 1},
+			{2, video + 64, 4, 1024, 1024, 1024, true, 1},
+		}, 42666666},
+	}
+	for i, test := range tests {
+		track := info.Tracks()[i]
+		samples, err := track.Samples()
+		if err != nil {
+			t.Fatalf("track %d: %v", track.ID(), err)
+		}
+		if !reflect.DeepEqual(samples, test.samples) {
+			t.Errorf("track %d,want %+v\ngot %+v", track.ID(), test.samples, samples)
+		}
+		if track.SampleCount() != uint32(len(test.samples)) || track.Duration() != test.duration {
+			t.Errorf("track %d,want %d samples of %v , got %d samples of %v",
+				track.ID(), len(test.samples), test.duration, track.SampleCount(), track.Duration())
+		}
+	}
+	if *info.Duration() != 375*time.Millisecond || info.RawDuration() != 375 {
+		t.Errorf("want duration 375ms , got %v (%d/%d)", info.Duration(), info.RawDuration(), info.TimeScale())
+	}
+
+	r, err := info.Tracks()[1].NewSampleReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range [...]string{"aaaa", "bbbb"} {
+		if _, got, err := r.Next(); err != nil || string(got) != want {
+			t.Errorf("audio sample,want %q , got %q , %v", want, got, err)
+		}
+	}
+	if point, err := info.Tracks()[0].Seek(250 * time.Millisecond); err != nil || point.Number != 4 {
+		t.Errorf("Seek(250ms),want sample 4 , got %+v , %v", point, err)
+	}
+
+	boxs, _ := p.Query("moof[1]/mfhd")
+	if got := boxs[0].Payload().(*mfhd).sequenceNumber; got != 2 {
+		t.Errorf("mfhd,want sequence number 2 , got %d", got)
+	}
+}
+
+func TestFragmentedHugeTRUN(t *testing.T) {
+	box := mkFullBox("trun", 0, 0, be(uint32(0x08000000)))
+	b := newTRUN(mkTestBox(box))
+	if err := b.scan(bytes.NewReader(box)); err != nil || b.entrys != nil {
+		t.Fatalf("want samples of defaults without entrys , got %d entrys , %v", len(b.entrys), err)
+	}
+
+	//samples of default size , or of size 0 , overrun file
+	for _, size := range [...]uint32{1024, 0} {
+		moov := mkBox("moov", mkMVHD(1000, 0), mkFragmentedTrak(1, "soun"),
+			mkBox("mvex", mkFullBox("trex", 0, 0, be(uint32(1), uint32(1), uint32(1024), size, uint32(0)))))
+		moof := mkBox("moof",
+			mkFullBox("mfhd", 0, 0, be(uint32(1))),
+			mkBox("traf", mkFullBox("tfhd", 0, 0, be(uint32(1))), box))
+		data := bytes.Join([][]byte{moov, moof, mkBox("mdat", make([]byte, 16))}, nil)
+
+		if _, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse(); !errors.Is(err, ErrInvalidData) {
+			t.Errorf("default size %d,want %v , got %v", size, ErrInvalidData, err)
+		}
+	}
+}
+
+func TestFragmentedSharedTRUN(t *testing.T) {
+	moov := mkBox("moov", mkMVHD(1000, 0), mkFragmentedTrak(1, "soun"),
+		mkBox("mvex", mkFullBox("trex", 0, 0, be(uint32(1), uint32(1), uint32(1024), uint32(4), uint32(0)))))
+
+	//each run fits in mdat alone , but all of them reuse the same data
+	moof := func(dataOffset int32) []byte {
+		boxs := [][]byte{mkFullBox("tfhd", 0, tfhdDefaultBaseIsMoof, be(uint32(1)))}
+		for i := 0; i < 10; i++ {
+			boxs = append(boxs, mkFullBox("trun", 0, trunDataOffset, be(uint32(100), dataOffset)))
+		}
+		return mkBox("moof", mkFullBox("mfhd", 0, 0, be(uint32(1))), mkBox("traf", boxs...))
+	}
+	data := bytes.Join([][]byte{moov, moof(int32(len(moof(0)) + 8)), mkBox("mdat", make([]byte, 400))}, nil)
+
+	if _, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse(); !errors.Is(err, ErrInvalidData) {
+		t.Errorf("want %v , got %v", ErrInvalidData, err)
+	}
+}
+
+func TestTRUN(t *testing.T) {
+	box := mkFullBox("trun", 0, trunSampleDuration|trunSampleSize|trunSampleFlags|trunSampleCompositionTime,
+		be(uint32(1), uint32(1), uint32(2), uint32(3), uint32(0xfffffffe)))
+	b := newTRUN(mkTestBox(box))
+	if err := b.scan(bytes.NewReader(box)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []trunEntry{{1, 2, 3, 0xfffffffe}}; !reflect.DeepEqual(b.entrys, want) { //unsigned in version 0
+		t.Errorf("want %+v , got %+v", want, b.entrys)
+	}
+
+	for _, test := range [...]struct {
+		box  []byte
+		want error
+	}{
+		{mkFullBox("trun", 0, trunSampleSize, be(uint32(2), uint32(1))), ErrTruncated},
+		{mkFullBox("trun", 0, trunDataOffset|trunFirstSampleFlags, be(uint32(0), uint32(0))), ErrTruncated},
+		{mkFullBox("trun", 2, 0, be(uint32(0))), ErrUnsupportedVersion},
+		{mkFullBox("trun", 0, 0), ErrTruncated},
+	} {
+		if err := newTRUN(mkTestBox(test.box)).scan(bytes.NewReader(test.box)); err != test.want {
+			t.Errorf("%x: want %v , got %v", test.box, test.want, err)
+		}
+	}
+
+	box = mkFullBox("tfhd", 0, tfhdBaseDataOffset|tfhdDefaultSampleSize, be(uint32(1), uint64(8)))
+	if err := newTFHD(mkTestBox(box)).scan(bytes.NewReader(box)); err != ErrTruncated {
+		t.Errorf("tfhd without default sample size,want %v , got %v", ErrTruncated, err)
+	}
+}
@@ -0,0 +1,444 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+//NAL unit types of HEVC parameter sets
+const (
+	hevcNALVPS = 32
+	hevcNALSPS = 33
+	hevcNALPPS = 34
+)
+
+//HEVCConfig HEVC decoder configuration record , hvcC
+type HEVCConfig struct {
+	Version                   uint8
+	ProfileSpace              uint8 //general_profile_space
+	HighTier                  bool  //general_tier_flag
+	Profile                   uint8 //general_profile_idc , e.g. 1 for Main , 2 for Main 10
+	ProfileCompatibilityFlags uint32
+	ConstraintIndicatorFlags  [6]byte
+	Level                     uint8 //general_level_idc , 30 times of level number
+	MinSpatialSegmentation    uint16
+	ParallelismType           uint8
+	ChromaFormat              uint8
+	BitDepthLuma              uint8
+	BitDepthChroma            uint8
+	AvgFrameRate              uint16 //in frames/(256 seconds) , 0 if unspecified
+	ConstantFrameRate         uint8
+	NumTemporalLayers         uint8
+	TemporalIDNested          bool
+	NALLengthSize             uint8 //size of NAL unit length in samples , 1 , 2 or 4
+	NALArrays                 []HEVCNALArray
+
+	SPSInfo *HEVCSPS //decoded first SPS , nil if there is not any or it fails to decode
+}
+
+//HEVCNALArray array of NAL units of the same type in HEVCConfig , e.g. VPS , SPS , PPS , SEI
+type HEVCNALArray struct {
+	Complete    bool //all NAL units of the type are in the array , none in samples
+	NALUnitType uint8
+	NALUnits    [][]byte
+}
+
+//NALUnits return NAL units of nalType in all arrays
+func (c *HEVCConfig) NALUnits(nalType uint8) [][]byte {
+	var units [][]byte
+	for _, array := range c.NALArrays {
+		if array.NALUnitType == nalType {
+			units = append(units, array.NALUnits...)
+		}
+	}
+	return units
+}
+
+//codecString return RFC 6381 codec string of sample entry codec , e.g. "hvc1.2.4.L153.B0" , see ISO/IEC 14496-15 Annex E
+func (c *HEVCConfig) codecString(codec string) string {
+	var b strings.Builder
+	b.WriteString(codec)
+	b.WriteByte('.')
+	if c.ProfileSpace > 0 {
+		b.WriteByte('A' + c.ProfileSpace - 1)
+	}
+	fmt.Fprintf(&b, "%d.%X.", c.Profile, bits.Reverse32(c.ProfileCompatibilityFlags))
+	if c.HighTier {
+		b.WriteByte('H')
+	} else {
+		b.WriteByte('L')
+	}
+	fmt.Fprintf(&b, "%d", c.Level)
+
+	n := len(c.ConstraintIndicatorFlags) //trailing zero bytes are omitted
+	for n > 0 && c.ConstraintIndicatorFlags[n-1] == 0 {
+		n--
+	}
+	for _, v := range c.ConstraintIndicatorFlags[:n] {
+		fmt.Fprintf(&b, ".%X", v)
+	}
+	return b.String()
+}
+
+//parseHEVCConfig parses data of hvcC box
+/**
+*configurationVersion					1
+*general_profile_space					2 bits
+*general_tier_flag						1 bit
+*general_profile_idc					5 bits
+*general_profile_compatibility_flags	4
+*general_constraint_indicator_flags		6
+*general_level_idc						1
+*_reserved								4 bits
+*min_spatial_segmentation_idc			12 bits
+*_reserved								6 bits
+*parallelismType						2 bits
+*_reserved								6 bits
+*chromaFormat							2 bits
+*_reserved								5 bits
+*bitDepthLumaMinus8						3 bits
+*_reserved								5 bits
+*bitDepthChromaMinus8					3 bits
+*avgFrameRate							2
+*constantFrameRate						2 bits
+*numTemporalLayers						3 bits
+*temporalIdNested						1 bit
+*lengthSizeMinusOne						2 bits
+*numOfArrays							1
+*for each array
+*	array_completeness					1 bit
+*	_reserved							1 bit
+*	NAL_unit_type						6 bits
+*	numNalus							2
+*	for each NAL unit
+*		nalUnitLength					2
+*		nalUnit							nalUnitLength
+ */
+func parseHEVCConfig(data []byte) (interface{}, error) {
+	if len(data) < 23 {
+		return nil, ErrTruncated
+	}
+	c := &HEVCConfig{
+		Version:                   data[0],
+		ProfileSpace:              data[1] >> 6,
+		HighTier:                  data[1]&0x20 != 0,
+		Profile:                   data[1] & 0x1f,
+		ProfileCompatibilityFlags: binary.BigEndian.Uint32(data[2:6]),
+		Level:                     data[12],
+		MinSpatialSegmentation:    binary.BigEndian.Uint16(data[13:15]) & 0xfff,
+		ParallelismType:           data[15] & 3,
+		ChromaFormat:              data[16] & 3,
+		BitDepthLuma:              data[17]&7 + 8,
+		BitDepthChroma:            data[18]&7 + 8,
+		AvgFrameRate:              binary.BigEndian.Uint16(data[19:21]),
+		ConstantFrameRate:         data[21] >> 6,
+		NumTemporalLayers:         data[21] >> 3 & 7,
+		TemporalIDNested:          data[21]&4 != 0,
+		NALLengthSize:             data[21]&3 + 1,
+	}
+	if c.Version != 1 {
+		return nil, ErrUnsupportedVersion
+	}
+	copy(c.ConstraintIndicatorFlags[:], data[6:12])
+
+	rest := data[23:]
+	for i := 0; i < int(data[22]); i++ {
+		if len(rest) < 3 {
+			return nil, ErrTruncated
+		}
+		array := HEVCNALArray{
+			Complete:    rest[0]&0x80 != 0,
+			NALUnitType: rest[0] & 0x3f,
+		}
+
+		var err error
+		n := int(binary.BigEndian.Uint16(rest[1:3]))
+		if array.NALUnits, rest, err = readParameterSets(rest[3:], n); err != nil {
+			return nil, err
+		}
+		c.NALArrays = append(c.NALArrays, array)
+	}
+
+	if sps := c.NALUnits(hevcNALSPS); len(sps) > 0 {
+		c.SPSInfo, _ = parseHEVCSPS(sps[0]) //config is still usable without it
+	}
+
+	return c, nil
+}
+
+//HEVCSPS fields decoded from HEVC sequence parameter set
+type HEVCSPS struct {
+	VPSID          uint8
+	MaxSubLayers   uint8
+	ID             uint32
+	ProfileSpace   uint8 //from general profile_tier_level
+	HighTier       bool
+	Profile        uint8
+	Level          uint8
+	ChromaFormat   uint8 //0 for monochrome , 1 for 4:2:0 , 2 for 4:2:2 , 3 for 4:4:4
+	BitDepthLuma   uint8
+	BitDepthChroma uint8
+
+	CodedWidth  uint32 //pic_width_in_luma_samples
+	CodedHeight uint32
+	CropLeft    uint32 //conformance window in pixels
+	CropRight   uint32
+	CropTop     uint32
+	CropBottom  uint32
+	Width       uint32 //after cropping
+	Height      uint32
+
+	NumUnitsInTick uint32 //0 if timing info is not present
+	TimeScale      uint32
+	VUI
+}
+
+//FrameRate return frame rate hinted by timing info , 0 if not present
+func (s *HEVCSPS) FrameRate() float64 {
+	if s.NumUnitsInTick == 0 {
+		return 0
+	}
+	return float64(s.TimeScale) / float64(s.NumUnitsInTick)
+}
+
+//parseHEVCSPS decodes SPS NAL unit nal , including its 2 bytes NAL header , up to timing info of VUI
+/**
+*forbidden_zero_bit , nal_unit_type , nuh_layer_id , nuh_temporal_id_plus1	2	//nal_unit_type 33
+*sps_video_parameter_set_id				u(4)
+*sps_max_sub_layers_minus1				u(3)
+*sps_temporal_id_nesting_flag			u(1)
+*profile_tier_level						//general one of 96 bits , followed by sub layers
+*sps_seq_parameter_set_id				ue(v)
+*chroma_format_idc						ue(v)
+*separate_colour_plane_flag				u(1)	//if chroma_format_idc == 3
+*pic_width_in_luma_samples				ue(v)
+*pic_height_in_luma_samples				ue(v)
+*conformance_window_flag				u(1)
+*conf_win_left_offset					ue(v)	//if conformance_window_flag , and following 3
+*conf_win_right_offset					ue(v)
+*conf_win_top_offset					ue(v)
+*conf_win_bottom_offset					ue(v)
+*bit_depth_luma_minus8					ue(v)
+*bit_depth_chroma_minus8				ue(v)
+*log2_max_pic_order_cnt_lsb_minus4		ue(v)
+*...									//sub layer ordering , coding block sizes , scaling list , pcm
+*num_short_term_ref_pic_sets			ue(v)
+*st_ref_pic_set							//for each
+*long_term_ref_pics_present_flag		u(1)
+*...									//long term ref pics
+*sps_temporal_mvp_enabled_flag			u(1)
+*strong_intra_smoothing_enabled_flag	u(1)
+*vui_parameters_present_flag			u(1)
+*vui_parameters							//if vui_parameters_present_flag
+ */
+func parseHEVCSPS(nal []byte) (*HEVCSPS, error) {
+	if len(nal) < 2 || nal[0]>>1&0x3f != hevcNALSPS {
+		return nil, ErrInvalidData
+	}
+	r := newBitReader(unescapeRBSP(nal[2:]))
+
+	s := &HEVCSPS{
+		VPSID:        uint8(r.readBits(4)),
+		MaxSubLayers: uint8(r.readBits(3)) + 1,
+		VUI:          defaultVUI(),
+	}
+	r.skip(1) //sps_temporal_id_nesting_flag
+
+	//general profile_tier_level
+	s.ProfileSpace = uint8(r.readBits(2))
+	s.HighTier = r.readFlag()
+	s.Profile = uint8(r.readBits(5))
+	r.skip(32 + 48) //compatibility and constraint flags
+	s.Level = uint8(r.readBits(8))
+
+	subLayers := int(s.MaxSubLayers) - 1
+	profilePresent := make([]bool, subLayers)
+	levelPresent := make([]bool, subLayers)
+	for i := 0; i < subLayers; i++ {
+		profilePresent[i] = r.readFlag()
+		levelPresent[i] = r.readFlag()
+	}
+	if subLayers > 0 {
+		r.skip(2 * (8 - subLayers)) //reserved_zero_2bits
+	}
+	for i := 0; i < subLayers; i++ {
+		if profilePresent[i] {
+			r.skip(88)
+		}
+		if levelPresent[i] {
+			r.skip(8)
+		}
+	}
+
+	s.ID = r.readUE()
+	s.ChromaFormat = uint8(r.readUE())
+	separateColourPlane := false
+	if s.ChromaFormat == 3 {
+		separateColourPlane = r.readFlag()
+	}
+	s.CodedWidth = r.readUE()
+	s.CodedHeight = r.readUE()
+	if r.readFlag() {
+		cropUnitX, cropUnitY := uint32(1), uint32(1)
+		if !separateColourPlane {
+			if s.ChromaFormat == 1 || s.ChromaFormat == 2 {
+				cropUnitX = 2
+			}
+			if s.ChromaFormat == 1 {
+				cropUnitY = 2
+			}
+		}
+		s.CropLeft = r.readUE() * cropUnitX
+		s.CropRight = r.readUE() * cropUnitX
+		s.CropTop = r.readUE() * cropUnitY
+		s.CropBottom = r.readUE() * cropUnitY
+	}
+	s.BitDepthLuma = uint8(r.readUE()) + 8
+	s.BitDepthChroma = uint8(r.readUE()) + 8
+	if r.err != nil {
+		return nil, r.err
+	}
+	if s.CropLeft+s.CropRight >= s.CodedWidth || s.CropTop+s.CropBottom >= s.CodedHeight {
+		return nil, ErrInvalidData
+	}
+	s.Width = s.CodedWidth - s.CropLeft - s.CropRight
+	s.Height = s.CodedHeight - s.CropTop - s.CropBottom
+
+	log2MaxPicOrderCntLsb := r.readUE() + 4
+	i := subLayers
+	if r.readFlag() { //sps_sub_layer_ordering_info_present_flag
+		i = 0
+	}
+	for ; i <= subLayers; i++ {
+		r.readUE() //sps_max_dec_pic_buffering_minus1
+		r.readUE() //sps_max_num_reorder_pics
+		r.readUE() //sps_max_latency_increase_plus1
+	}
+	for i := 0; i < 6; i++ { //coding block and transform block sizes , transform hierarchy depths
+		r.readUE()
+	}
+	if r.readFlag() && r.readFlag() { //scaling_list_enabled_flag , sps_scaling_list_data_present_flag
+		skipHEVCScalingListData(r)
+	}
+	r.skip(2) //amp_enabled_flag , sample_adaptive_offset_enabled_flag
+
+	if r.readFlag() { //pcm_enabled_flag
+		r.skip(8)
+		r.readUE()
+		r.readUE()
+		r.skip(1)
+	}
+
+	numShortTermRefPicSets := r.readUE()
+	if numShortTermRefPicSets > 64 {
+		return nil, ErrInvalidData
+	}
+	numDeltaPocs := make([]uint32, numShortTermRefPicSets)
+	for i := uint32(0); i < numShortTermRefPicSets && r.err == nil; i++ {
+		numDeltaPocs[i] = skipShortTermRefPicSet(r, i, numDeltaPocs)
+	}
+	if r.readFlag() { //long_term_ref_pics_present_flag
+		n := r.readUE()
+		for i := uint32(0); i < n && r.err == nil; i++ {
+			r.skip(int(log2MaxPicOrderCntLsb) + 1) //lt_ref_pic_poc_lsb_sps , used_by_curr_pic_lt_sps_flag
+		}
+	}
+	r.skip(2) //sps_temporal_mvp_enabled_flag , strong_intra_smoothing_enabled_flag
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if r.readFlag() {
+		s.parseVUI(r)
+		if r.err != nil {
+			return nil, r.err
+		}
+	}
+
+	return s, nil
+}
+
+//skipHEVCScalingListData skips scaling_list_data in r
+func skipHEVCScalingListData(r *bitReader) {
+	for sizeID := 0; sizeID < 4; sizeID++ {
+		step := 1
+		if sizeID == 3 {
+			step = 3
+		}
+		for matrixID := 0; matrixID < 6; matrixID += step {
+			if !r.readFlag() { //scaling_list_pred_mode_flag
+				r.readUE() //scaling_list_pred_matrix_id_delta
+				continue
+			}
+			coefNum := 1 << uint(4+sizeID<<1)
+			if coefNum > 64 {
+				coefNum = 64
+			}
+			if sizeID > 1 {
+				r.readSE() //scaling_list_dc_coef_minus8
+			}
+			for i := 0; i < coefNum && r.err == nil; i++ {
+				r.readSE()
+			}
+		}
+	}
+}
+
+//skipShortTermRefPicSet skips st_ref_pic_set(idx) in SPS from r , numDeltaPocs are of the previous sets ,
+//	return NumDeltaPocs of this set
+func skipShortTermRefPicSet(r *bitReader, idx uint32, numDeltaPocs []uint32) uint32 {
+	if idx != 0 && r.readFlag() { //inter_ref_pic_set_prediction_flag
+		r.skip(1)  //delta_rps_sign
+		r.readUE() //abs_delta_rps_minus1
+		n := uint32(0)
+		for j := uint32(0); j <= numDeltaPocs[idx-1] && r.err == nil; j++ {
+			if r.readFlag() || r.readFlag() { //used_by_curr_pic_flag , use_delta_flag
+				n++
+			}
+		}
+		return n
+	}
+
+	numNegative := r.readUE()
+	numPositive := r.readUE()
+	if numNegative > 16 || numPositive > 16 {
+		r.err = ErrInvalidData
+		return 0
+	}
+	for i := uint32(0); i < numNegative+numPositive && r.err == nil; i++ {
+		r.readUE() //delta_poc_minus1
+		r.skip(1)  //used_by_curr_pic_flag
+	}
+	return numNegative + numPositive
+}
+
+//parseVUI decodes VUI parameters in r up to timing info
+/**
+*...								//as AVC , up to chroma sample location
+*neutral_chroma_indication_flag		u(1)
+*field_seq_flag						u(1)
+*frame_field_info_present_flag		u(1)
+*default_display_window_flag		u(1)
+*def_disp_win_left_offset			ue(v)	//if default_display_window_flag , and following 3
+*def_disp_win_right_offset			ue(v)
+*def_disp_win_top_offset			ue(v)
+*def_disp_win_bottom_offset			ue(v)
+*vui_timing_info_present_flag		u(1)
+*vui_num_units_in_tick				u(32)
+*vui_time_scale						u(32)
+ */
+func (s *HEVCSPS) parseVUI(r *bitReader) {
+	s.VUI.parse(r)
+	r.skip(3)
+	if r.readFlag() {
+		for i := 0; i < 4; i++ {
+			r.readUE()
+		}
+	}
+	if r.readFlag() {
+		s.NumUnitsInTick = uint32(r.readBits(32))
+		s.TimeScale = uint32(r.readBits(32))
+	}
+}
@@ -0,0 +1,82 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+)
+
+//testMain10SPS is SPS of Main 10 profile , level 5.1 , 3840x2160 4:2:0 10 bits ,
+//	with BT.2020 PQ colour , 50 fps timing and 2 short term reference picture sets
+var testMain10SPS = []byte{
+	0x42, 0x01, 0x01, 0x02, 0x20, 0x00, 0x00, 0x03, 0x00, 0xb0, 0x00, 0x00, 0x03, 0x00, 0x00,
+	0x03, 0x00, 0x99, 0xa0, 0x01, 0xe0, 0x20, 0x02, 0x1c, 0x4d, 0x96, 0x57, 0x92, 0x4d, 0x9a,
+	0xf6, 0xb9, 0xa8, 0x48, 0x80, 0x48, 0x20, 0x00, 0x00, 0x03, 0x00, 0x20, 0x00, 0x00, 0x06,
+	0x41,
+}
+
+//mkHVCC return data of hvcC box of Main 10 profile with sps
+func mkHVCC(sps []byte) []byte {
+	return bytes.Join([][]byte{
+		{1, 0x02}, be(uint32(0x20000000)), {0xb0, 0, 0, 0, 0, 0, 153},
+		be(uint16(0xf000)), {0xfc, 0xfd, 0xfa, 0xfa}, be(uint16(0)), {0x0f},
+		{1, 0x80 | hevcNALSPS}, be(uint16(1), uint16(len(sps))), sps,
+	}, nil)
+}
+
+func TestParseHEVCConfig(t *testing.T) {
+	got, err := parseHEVCConfig(mkHVCC(testMain10SPS))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := got.(*HEVCConfig)
+	if c.Profile != 2 || c.Level != 153 || c.HighTier || c.ChromaFormat != 1 || c.BitDepthLuma != 10 ||
+		c.NumTemporalLayers != 1 || !c.TemporalIDNested || c.NALLengthSize != 4 ||
+		len(c.NALArrays) != 1 || !c.NALArrays[0].Complete || len(c.NALUnits(hevcNALSPS)) != 1 {
+		t.Errorf("got %+v", c)
+	}
+	if got := c.codecString("hvc1"); got != "hvc1.2.4.L153.B0" {
+		t.Errorf("codecString,want hvc1.2.4.L153.B0 , got %s", got)
+	}
+
+	want := HEVCSPS{
+		MaxSubLayers: 1, Profile: 2, Level: 153, ChromaFormat: 1, BitDepthLuma: 10, BitDepthChroma: 10,
+		CodedWidth: 3840, CodedHeight: 2160, Width: 3840, Height: 2160, NumUnitsInTick: 1, TimeScale: 50,
+		VUI: VUI{ColourPrimaries: 9, TransferCharacteristics: 16, MatrixCoefficients: 9},
+	}
+	if *c.SPSInfo != want {
+		t.Errorf("want %+v , got %+v", want, *c.SPSInfo)
+	}
+	if !c.SPSInfo.HDR() || c.SPSInfo.FrameRate() != 50 {
+		t.Errorf("want HDR at 50 fps , got %v , %f", c.SPSInfo.HDR(), c.SPSInfo.FrameRate())
+	}
+
+	c.ProfileSpace, c.HighTier, c.Profile, c.ProfileCompatibilityFlags = 1, true, 1, 0x60000000
+	c.ConstraintIndicatorFlags = [6]byte{0x90, 0, 0x08}
+	if got := c.codecString("hev1"); got != "hev1.A1.6.H153.90.0.8" {
+		t.Errorf("codecString,want hev1.A1.6.H153.90.0.8 , got %s", got)
+	}
+
+	for _, data := range [...][]byte{
+		mkHVCC(testMain10SPS)[:22],
+		append([]byte{2}, mkHVCC(testMain10SPS)[1:]...),
+	} {
+		if _, err := parseHEVCConfig(data); err == nil {
+			t.Errorf("parseHEVCConfig(%x),want error", data)
+		}
+	}
+
+	//config is kept if SPS fails to decode
+	for _, data := range [...][]byte{
+		mkHVCC(testMain10SPS[:20]),
+		mkHVCC([]byte{0x40, 0x01, 0x0c}), //VPS in SPS array
+	} {
+		got, err := parseHEVCConfig(data)
+		if err != nil {
+			t.Errorf("parseHEVCConfig(%x),got error %v", data, err)
+			continue
+		}
+		if c := got.(*HEVCConfig); len(c.NALUnits(hevcNALSPS)) != 1 || c.SPSInfo != nil {
+			t.Errorf("parseHEVCConfig(%x),want SPS without SPSInfo , got %+v", data, c)
+		}
+	}
+}
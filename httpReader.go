@@ -0,0 +1,244 @@
+package mp4parser
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultBlockSize   = 32 << 10 //32KiB
+	defaultCacheBlocks = 128
+)
+
+//HTTPReader reads remote file by HTTP range requests , implements io.ReaderAt
+//	data are fetched and cached in blocks , missing adjacent blocks needed by one read
+//	are fetched by a single request
+type HTTPReader struct {
+	client *http.Client
+	url    string
+	size   int64
+
+	blockSize   int64
+	cacheBlocks int
+
+	mu     sync.Mutex              // guards fields below , held while fetching
+	blocks map[int64]*list.Element // block index -> element of lru
+	lru    *list.List              // *httpBlock , most recently used at front
+
+	bytesFetched int64
+	requests     int
+}
+
+//httpBlock cached block of remote file
+type httpBlock struct {
+	index int64
+	data  []byte
+}
+
+//NewHTTPReader return new HTTPReader of url with default block size and cache size ,
+//	client is http.DefaultClient if nil
+func NewHTTPReader(client *http.Client, url string) (*HTTPReader, error) {
+	return NewHTTPReaderSize(client, url, defaultBlockSize, defaultCacheBlocks)
+}
+
+//NewHTTPReaderSize return new HTTPReader of url which fetches blockSize bytes at least per request
+//	and caches at most cacheBlocks blocks , it fetches the first block to get size of remote file
+func NewHTTPReaderSize(client *http.Client, url string, blockSize, cacheBlocks int) (*HTTPReader, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+	if cacheBlocks <= 0 {
+		cacheBlocks = defaultCacheBlocks
+	}
+
+	r := &HTTPReader{
+		client:      client,
+		url:         url,
+		size:        -1,
+		blockSize:   int64(blockSize),
+		cacheBlocks: cacheBlocks,
+		blocks:      make(map[int64]*list.Element),
+		lru:         list.New(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.fetch(0, 1); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+//Size return size of remote file
+func (r *HTTPReader) Size() int64 {
+	return r.size
+}
+
+//BytesFetched return number of bytes received from remote
+func (r *HTTPReader) BytesFetched() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bytesFetched
+}
+
+//Requests return number of requests issued
+func (r *HTTPReader) Requests() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.requests
+}
+
+//ReadAt implements io.ReaderAt
+func (r *HTTPReader) ReadAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, fmt.Errorf("HTTPReader.ReadAt: negative offset %d", off)
+	}
+	if off >= r.size {
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p)) //exclusive
+	if end > r.size {
+		end = r.size
+		err = io.EOF
+	}
+	if end == off {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	first, last := off/r.blockSize, (end-1)/r.blockSize
+
+	for i := first; i <= last; {
+		if elem, ok := r.blocks[i]; ok {
+			r.lru.MoveToFront(elem)
+			n += r.copyBlock(p[n:], elem.Value.(*httpBlock).data, i*r.blockSize, off, end)
+			i++
+			continue
+		}
+
+		//fetch missing adjacent blocks in one request
+		j := i + 1
+		for j <= last {
+			if _, ok := r.blocks[j]; ok {
+				break
+			}
+			j++
+		}
+		data, fetchErr := r.fetch(i, j-i)
+		if fetchErr != nil {
+			return n, fetchErr
+		}
+		n += r.copyBlock(p[n:], data, i*r.blockSize, off, end)
+		i = j
+	}
+
+	return n, err
+}
+
+//copyBlock copies part of data starting at dataStart in file , within range [off,end) , into p
+func (r *HTTPReader) copyBlock(p, data []byte, dataStart, off, end int64) int {
+	from, to := int64(0), int64(len(data))
+	if off > dataStart {
+		from = off - dataStart
+	}
+	if end < dataStart+to {
+		to = end - dataStart
+	}
+	return copy(p, data[from:to])
+}
+
+//fetch requests count blocks from the index-th block , caches and return them , r.mu must be held
+func (r *HTTPReader) fetch(index, count int64) ([]byte, error) {
+	start := index * r.blockSize
+	end := start + count*r.blockSize - 1 //inclusive
+	if r.size >= 0 && end >= r.size {
+		end = r.size - 1
+	}
+
+	req, err := http.NewRequest(http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	r.requests++
+
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("HTTPReader.fetch: %s: unexpected status %q for range %d-%d", r.url, resp.Status, start, end)
+	}
+
+	if r.size < 0 {
+		if r.size, err = parseContentRangeSize(resp.Header.Get("Content-Range")); err != nil {
+			return nil, err
+		}
+		if end >= r.size {
+			end = r.size - 1
+		}
+	}
+
+	data := make([]byte, end-start+1)
+	n, err := io.ReadFull(resp.Body, data)
+	r.bytesFetched += int64(n)
+	if err != nil {
+		return nil, fmt.Errorf("HTTPReader.fetch: %s: range %d-%d: %v", r.url, start, end, err)
+	}
+
+	for i := int64(0); i < count && i*r.blockSize < int64(len(data)); i++ {
+		to := (i + 1) * r.blockSize
+		if to > int64(len(data)) {
+			to = int64(len(data))
+		}
+		r.addBlock(&httpBlock{index: index + i, data: data[i*r.blockSize : to]})
+	}
+
+	return data, nil
+}
+
+//addBlock caches block and evicts the least recently used one if cache is full , r.mu must be held
+func (r *HTTPReader) addBlock(block *httpBlock) {
+	if elem, ok := r.blocks[block.index]; ok {
+		elem.Value = block
+		r.lru.MoveToFront(elem)
+		return
+	}
+
+	r.blocks[block.index] = r.lru.PushFront(block)
+
+	for r.lru.Len() > r.cacheBlocks {
+		oldest := r.lru.Back()
+		r.lru.Remove(oldest)
+		delete(r.blocks, oldest.Value.(*httpBlock).index)
+	}
+}
+
+//parseContentRangeSize return the complete length in Content-Range header ,e.g. "bytes 0-99/1234"
+func parseContentRangeSize(contentRange string) (int64, error) {
+	i := strings.LastIndexByte(contentRange, '/')
+	if !strings.HasPrefix(contentRange, "bytes ") || i < 0 {
+		return 0, fmt.Errorf("parseContentRangeSize: invalid Content-Range %q", contentRange)
+	}
+
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("parseContentRangeSize: unknown size in Content-Range %q", contentRange)
+	}
+
+	return size, nil
+}
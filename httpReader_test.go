@@ -0,0 +1,133 @@
+package mp4parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+//newTestServer serves data with range requests support ,counting requests received
+func newTestServer(data []byte, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		http.ServeContent(w, r, "sample.mp4", time.Time{}, bytes.NewReader(data))
+	}))
+}
+
+func TestHTTPReaderParse(t *testing.T) {
+	data, err := ioutil.ReadFile(`./sample/sample.mp4`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	server := newTestServer(data, &requests)
+	defer server.Close()
+
+	r, err := NewHTTPReaderSize(nil, server.URL, 4<<10, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Size() != int64(len(data)) {
+		t.Errorf("Size(),want %d , got %d", len(data), r.Size())
+	}
+
+	want, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewReaderAtParser(r, -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("want:\n%v\ngot:\n%v", want, got)
+	}
+
+	if r.Requests() != requests {
+		t.Errorf("Requests(),want %d , got %d", requests, r.Requests())
+	}
+	if r.BytesFetched() > int64(len(data))/8 || r.Requests() > 8 {
+		t.Errorf("fetched %d bytes by %d requests of %d bytes file", r.BytesFetched(), r.Requests(), len(data))
+	}
+}
+
+func TestHTTPReaderReadAt(t *testing.T) {
+	data := make([]byte, 10000)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	requests := 0
+	server := newTestServer(data, &requests)
+	defer server.Close()
+
+	r, err := NewHTTPReaderSize(nil, server.URL, 100, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		off, n       int
+		wantN        int
+		wantRequests int //requests issued by this read
+	}{
+		{0, 50, 50, 0},        //first block is fetched by constructor
+		{150, 400, 400, 1},    //4 missing adjacent blocks in one request
+		{180, 100, 100, 0},    //cached
+		{1000, 2000, 2000, 1}, //larger than cache
+		{9950, 100, 50, 1},    //end of file
+	}
+
+	for _, test := range tests {
+		before := r.Requests()
+		p := make([]byte, test.n)
+		n, err := r.ReadAt(p, int64(test.off))
+		if n != test.wantN {
+			t.Errorf("ReadAt(%d,%d),want n=%d , got %d, %v", test.off, test.n, test.wantN, n, err)
+		}
+		if n < test.n && err == nil {
+			t.Errorf("ReadAt(%d,%d),want error for short read", test.off, test.n)
+		}
+		if !bytes.Equal(p[:n], data[test.off:test.off+n]) {
+			t.Errorf("ReadAt(%d,%d),got wrong data", test.off, test.n)
+		}
+		if got := r.Requests() - before; got != test.wantRequests {
+			t.Errorf("ReadAt(%d,%d),want %d requests , got %d", test.off, test.n, test.wantRequests, got)
+		}
+	}
+}
+
+func TestHTTPReaderNoRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("no range support"))
+	}))
+	defer server.Close()
+
+	if _, err := NewHTTPReader(nil, server.URL); err == nil {
+		t.Error("want error when server ignores range")
+	}
+}
+
+func TestParseContentRangeSize(t *testing.T) {
+	tests := [...]struct {
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{"bytes 0-99/1234", 1234, false},
+		{"bytes 0-99/*", 0, true},
+		{"", 0, true},
+		{"items 0-1/2", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseContentRangeSize(test.input)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("input %q want %d,%t , got %d,%v", test.input, test.want, test.wantErr, got, err)
+		}
+	}
+}
@@ -1,23 +1,45 @@
 package mp4parser
 
-import "time"
-import "fmt"
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
 
 //MediaInfo contain media information
 type MediaInfo struct {
 	width             float64 //
-	height            float64 //found in tkhd
-	soundSamplingRate uint32
+	height            float64 //found in tkhd of the first video track
+	soundSamplingRate uint32  //sample rate of the first sound track
+
+	tracks []*Track
 
 	creationTime *time.Time
 	modifTime    *time.Time
-	duration     *time.Duration // result of duration/time_scale(field in mvhd)
+	duration     *time.Duration // the longest track with edit lists applied , or result of duration/time_scale(field in mvhd)
+	timeScale    uint32         //time_scale in mvhd
+	rawDuration  uint64         //duration in mvhd
+
+	warnings []*ParseError //recovered errors in lenient mode
 }
 
 func (m *MediaInfo) String() string {
-	return fmt.Sprintf(
+	buffer := new(bytes.Buffer)
+	buffer.WriteString(fmt.Sprintf(
 		"creationTime:%v\nmodifTime:%v\nduration:%v\nwidth:%.2f\theight:%.2f\tsound samlping rate:%dHz",
-		m.creationTime, m.modifTime, m.duration, m.width, m.height, m.soundSamplingRate)
+		m.creationTime, m.modifTime, m.duration, m.width, m.height, m.soundSamplingRate))
+
+	for _, t := range m.tracks {
+		buffer.WriteString("\n")
+		buffer.WriteString(t.String())
+	}
+
+	return buffer.String()
+}
+
+//Tracks return information of each track in file order
+func (m *MediaInfo) Tracks() []*Track {
+	return m.tracks
 }
 
 func (m *MediaInfo) Width() float64 {
@@ -32,7 +54,11 @@ func (m *MediaInfo) SamplingRate() uint32 {
 	return m.soundSamplingRate
 }
 
+//CreationTime return creation_time in mvhd , the zero time if mvhd is missing in lenient mode
 func (m *MediaInfo) CreationTime() time.Time {
+	if m.creationTime == nil {
+		return time.Time{}
+	}
 	return *m.creationTime
 }
 
@@ -40,6 +66,23 @@ func (m *MediaInfo) ModifiedTime() *time.Time {
 	return m.modifTime
 }
 
+//Duration return duration of movie , truncated to nanosecond ,
+//	that of the longest track with edit lists applied , RawDuration/TimeScale seconds if there is no track
 func (m *MediaInfo) Duration() *time.Duration {
 	return m.duration
 }
+
+//TimeScale return number of time units per second of movie , found in mvhd
+func (m *MediaInfo) TimeScale() uint32 {
+	return m.timeScale
+}
+
+//RawDuration return duration of movie in units of TimeScale , found in mvhd , or mehd if that in mvhd is 0
+func (m *MediaInfo) RawDuration() uint64 {
+	return m.rawDuration
+}
+
+//Warnings return errors recovered in lenient mode , in the order they were found
+func (m *MediaInfo) Warnings() []*ParseError {
+	return m.warnings
+}
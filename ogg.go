@@ -0,0 +1,233 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+//errNotOpus is returned when converting track of codec other than Opus to Ogg Opus
+var errNotOpus = errors.New("mp4parser: track is not Opus")
+
+//Ogg page header types
+const (
+	oggContinued = 0x01
+	oggBOS       = 0x02 //beginning of stream
+	oggEOS       = 0x04 //end of stream
+)
+
+//oggMaxSegments max number of lacing values in a page
+const oggMaxSegments = 255
+
+//opusGranuleRate Opus granule positions are counted at 48kHz
+const opusGranuleRate = 48000
+
+//opusVendor vendor string in OpusTags
+const opusVendor = "mp4parser"
+
+//oggCRCTable lookup table of CRC-32 of Ogg , polynomial 0x04c11db7 , not reflected
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return
+}()
+
+//oggCRC return checksum of Ogg page in data , whose CRC field is zero
+func oggCRC(data []byte) uint32 {
+	crc := uint32(0)
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+//OggOpusWriter writes Opus samples in Ogg pages , as RFC 7845 , a packet per page ,
+//	it reuses its buffer and is not safe for concurrent use
+type OggOpusWriter struct {
+	w         io.Writer
+	serial    uint32
+	sequence  uint32
+	timeScale uint32 //of media
+
+	pending    []byte //the last packet , written with EOS by Close
+	hasPending bool
+	granule    int64 //of pending packet
+	mediaTime  int64 //end of samples written , in timeScale
+
+	buf []byte
+}
+
+//NewOggOpusWriter return OggOpusWriter writing to w , with dOps of the first sample entry of t ,
+//	OpusHead and OpusTags are written at once , return an error if t is not Opus , or an error of writing
+/**
+*OpusHead
+*magic					8	//"OpusHead"
+*version				1	//1
+*channel_count			1
+*pre_skip				2	//little endian as following
+*input_sample_rate		4
+*output_gain			2
+*mapping_family			1
+*stream_count			1	//if mapping_family != 0
+*coupled_count			1
+*channel_mapping		channel_count
+*
+*OpusTags
+*magic					8	//"OpusTags"
+*vendor_length			4
+*vendor					vendor_length
+*comment_count			4
+ */
+func (t *Track) NewOggOpusWriter(w io.Writer) (*OggOpusWriter, error) {
+	if t.audio == nil || t.audio.Opus == nil {
+		return nil, errNotOpus
+	}
+	c := t.audio.Opus
+
+	o := &OggOpusWriter{
+		w:         w,
+		serial:    t.id,
+		timeScale: t.timeScale,
+	}
+
+	head := append([]byte("OpusHead"), 1, c.OutputChannelCount)
+	head = append(head, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint16(head[10:12], c.PreSkip)
+	binary.LittleEndian.PutUint32(head[12:16], c.InputSampleRate)
+	binary.LittleEndian.PutUint16(head[16:18], uint16(c.OutputGain))
+	head = append(head, c.ChannelMappingFamily)
+	if c.ChannelMappingFamily != 0 {
+		head = append(head, c.StreamCount, c.CoupledCount)
+		head = append(head, c.ChannelMapping...)
+	}
+	if err := o.writePacket(head, 0, oggBOS); err != nil {
+		return nil, err
+	}
+
+	tags := append([]byte("OpusTags"), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(tags[8:12], uint32(len(opusVendor)))
+	tags = append(tags, opusVendor...)
+	tags = append(tags, 0, 0, 0, 0)
+	if err := o.writePacket(tags, 0, 0); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+//WriteSample writes data of a sample , an Opus packet , of duration in time scale of media ,
+//	the packet is held until the next call or Close , return an error of writing
+func (o *OggOpusWriter) WriteSample(data []byte, duration uint32) error {
+	if o.hasPending {
+		if err := o.writePacket(o.pending, o.granule, 0); err != nil {
+			return err
+		}
+	}
+
+	o.pending = append(o.pending[:0], data...)
+	o.hasPending = true
+	o.mediaTime += int64(duration)
+	o.granule = scaleTime(o.mediaTime, opusGranuleRate, o.timeScale)
+	return nil
+}
+
+//Close writes the last packet with end of stream flag , it does not close the underlying writer ,
+//	return an error of writing
+func (o *OggOpusWriter) Close() error {
+	if !o.hasPending {
+		return nil
+	}
+	o.hasPending = false
+	return o.writePacket(o.pending, o.granule, oggEOS)
+}
+
+//writePacket writes packet in pages , the last page is of granule and headerType ,
+//	packet longer than a page continues in following pages
+/**
+*capture_pattern		4	//"OggS"
+*version				1	//0
+*header_type			1
+*granule_position		8	//little endian as following , -1 if no packet ends in the page
+*serial_number			4
+*page_sequence_number	4
+*CRC_checksum			4
+*page_segments			1
+*segment_table			page_segments	//lacing values , a packet ends with a value less than 255
+*data					sum of segment_table
+ */
+func (o *OggOpusWriter) writePacket(packet []byte, granule int64, headerType byte) error {
+	segments := len(packet)/255 + 1
+	for first := true; first || segments > 0; first = false {
+		n, pageType, pageGranule := segments, headerType, granule
+		if n > oggMaxSegments {
+			n, pageType, pageGranule = oggMaxSegments, headerType&oggBOS, -1
+		}
+		if !first {
+			pageType = pageType&^oggBOS | oggContinued
+		}
+		size := n * 255
+		if size > len(packet) {
+			size = len(packet)
+		}
+
+		o.buf = append(o.buf[:0], "OggS"...)
+		o.buf = append(o.buf, 0, pageType)
+		o.buf = append(o.buf, make([]byte, 20)...)
+		binary.LittleEndian.PutUint64(o.buf[6:14], uint64(pageGranule))
+		binary.LittleEndian.PutUint32(o.buf[14:18], o.serial)
+		binary.LittleEndian.PutUint32(o.buf[18:22], o.sequence)
+		o.buf = append(o.buf, byte(n))
+		for i := 0; i < n; i++ {
+			lacing := byte(255)
+			if i == segments-1 { //the last segment of packet
+				lacing = byte(len(packet) - i*255)
+			}
+			o.buf = append(o.buf, lacing)
+		}
+		o.buf = append(o.buf, packet[:size]...)
+		binary.LittleEndian.PutUint32(o.buf[22:26], oggCRC(o.buf))
+
+		if _, err := o.w.Write(o.buf); err != nil {
+			return err
+		}
+		o.sequence++
+		packet = packet[size:]
+		segments -= n
+	}
+	return nil
+}
+
+//WriteOggOpus writes all samples of t to w as Ogg Opus stream , e.g. .opus file ,
+//	return an error if t is not Opus , or any of reading and writing
+func (t *Track) WriteOggOpus(w io.Writer) error {
+	o, err := t.NewOggOpusWriter(w)
+	if err != nil {
+		return err
+	}
+	r, err := t.NewSampleReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		s, data, err := r.Next()
+		if err == io.EOF {
+			return o.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if err = o.WriteSample(data, s.Duration); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package mp4parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestOggCRC(t *testing.T) {
+	if got := oggCRC([]byte("123456789")); got != 0x89a1897f {
+		t.Errorf("want 89a1897f , got %x", got)
+	}
+}
+
+//oggPage page read back from Ogg stream
+type oggPage struct {
+	headerType byte
+	granule    int64
+	sequence   uint32
+	lacing     []byte
+	data       []byte
+}
+
+//readOggPages splits data into pages , checking their CRC
+func readOggPages(t *testing.T, data []byte) []oggPage {
+	var pages []oggPage
+	for len(data) > 0 {
+		if len(data) < 27 || string(data[:4]) != "OggS" || data[4] != 0 {
+			t.Fatalf("bad page %x", data)
+		}
+		n := int(data[26])
+		size := 27 + n
+		for _, v := range data[27 : 27+n] {
+			size += int(v)
+		}
+		page := append([]byte(nil), data[:size]...)
+		crc := binary.LittleEndian.Uint32(page[22:26])
+		copy(page[22:26], []byte{0, 0, 0, 0})
+		if oggCRC(page) != crc || binary.LittleEndian.Uint32(page[14:18]) != 7 {
+			t.Errorf("page %d,bad CRC or serial number", len(pages))
+		}
+		pages = append(pages, oggPage{
+			headerType: page[5],
+			granule:    int64(binary.LittleEndian.Uint64(page[6:14])),
+			sequence:   binary.LittleEndian.Uint32(page[18:22]),
+			lacing:     page[27 : 27+n],
+			data:       page[27+n:],
+		})
+		data = data[size:]
+	}
+	return pages
+}
+
+func TestOggOpusWriter(t *testing.T) {
+	track := &Track{id: 7, timeScale: 48000, audio: &AudioInfo{Opus: &OpusConfig{
+		OutputChannelCount:   6,
+		PreSkip:              312,
+		InputSampleRate:      44100,
+		OutputGain:           -256,
+		ChannelMappingFamily: 1,
+		StreamCount:          4,
+		CoupledCount:         2,
+		ChannelMapping:       []uint8{0, 4, 1, 2, 3, 5},
+	}}}
+
+	out := new(bytes.Buffer)
+	o, err := track.NewOggOpusWriter(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := bytes.Repeat([]byte{0xab}, 255*255+10)
+	for _, packet := range [...][]byte{{1, 2, 3}, big, make([]byte, 510)} {
+		if err := o.WriteSample(packet, 960); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	pages := readOggPages(t, out.Bytes())
+	head := []byte{'O', 'p', 'u', 's', 'H', 'e', 'a', 'd', 1, 6, 0x38, 0x01, 0x44, 0xac, 0, 0, 0, 0xff, 1, 4, 2, 0, 4, 1, 2, 3, 5}
+	want := [...]struct {
+		headerType byte
+		granule    int64
+		segments   int
+		data       []byte
+	}{
+		{oggBOS, 0, 1, head},
+		{0, 0, 1, append([]byte("OpusTags\x09\x00\x00\x00"+opusVendor), 0, 0, 0, 0)},
+		{0, 960, 1, []byte{1, 2, 3}},
+		{0, -1, 255, big[:255*255]},
+		{oggContinued, 1920, 1, big[255*255:]},
+		{oggEOS, 2880, 3, make([]byte, 510)}, //255 , 255 , 0
+	}
+	if len(pages) != len(want) {
+		t.Fatalf("want %d pages , got %d", len(want), len(pages))
+	}
+	for i, w := range want {
+		got := pages[i]
+		if got.headerType != w.headerType || got.granule != w.granule || got.sequence != uint32(i) ||
+			len(got.lacing) != w.segments || !bytes.Equal(got.data, w.data) {
+			t.Errorf("page %d,want %+v , got type %x granule %d sequence %d %d segments %d bytes",
+				i, w, got.headerType, got.granule, got.sequence, len(got.lacing), len(got.data))
+		}
+	}
+	if pages[5].lacing[2] != 0 {
+		t.Errorf("want packet of 510 bytes ends with lacing value 0 , got %v", pages[5].lacing)
+	}
+
+	if _, err := (&Track{audio: &AudioInfo{}}).NewOggOpusWriter(out); err != errNotOpus {
+		t.Errorf("want %v , got %v", errNotOpus, err)
+	}
+}
@@ -3,7 +3,7 @@ package mp4parser
 
 import (
 	"encoding/binary"
-	"fmt"
+	"errors"
 	"io"
 	"os"
 	"time"
@@ -15,61 +15,147 @@ const (
 	seekFromEnd
 )
 
+//errNotParsed is returned when querying before parsing
+var errNotParsed = errors.New("mp4parser: not parsed yet")
+
 //Parser parses file into media meta infos
 type Parser struct {
-	file      *os.File
+	reader    io.ReaderAt
+	size      int64 //size of data in reader , negative if unknown yet
 	rootBox   *RootBox
 	dataBoxs  map[string][]dataBox
 	mediaInfo *MediaInfo
 
 	currentTrack string // parsing track type
+
+	lenient  bool
+	warnings []*ParseError
 }
 
-//NewParser return new Parser
-func NewParser(file *os.File) *Parser {
-	return &Parser{
-		file:      file,
+//Option configures Parser
+type Option func(*Parser)
+
+//Lenient makes Parser recover from malformed data instead of failing,
+//	e.g. boxes overrun their parent are clamped , unreadable boxes are skipped ,
+//	what were recovered are reported by MediaInfo.Warnings
+func Lenient() Option {
+	return func(p *Parser) {
+		p.lenient = true
+	}
+}
+
+//NewParser return new Parser reading from file
+func NewParser(file *os.File, opts ...Option) *Parser {
+	return NewReaderAtParser(file, -1, opts...)
+}
+
+//NewReaderAtParser return new Parser reading from r , which holds size bytes of data ,
+//	if size < 0 , it is taken from r.Stat() or r.Size() at parsing
+func NewReaderAtParser(r io.ReaderAt, size int64, opts ...Option) *Parser {
+	p := &Parser{
+		reader:    r,
+		size:      size,
 		rootBox:   newRootBox(),
 		dataBoxs:  make(map[string][]dataBox),
 		mediaInfo: new(MediaInfo),
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+//NewReadSeekerParser return new Parser reading from rs ,
+//	rs should not be used by others until parsing finished
+func NewReadSeekerParser(rs io.ReadSeeker, opts ...Option) *Parser {
+	return NewReaderAtParser(&readSeekerAt{rs: rs}, -1, opts...)
 }
 
 //Parse parses the mp4 file , return mediaInfo and an error ,if any
 func (p *Parser) Parse() (*MediaInfo, error) {
-
-	fileInfo, err := p.file.Stat() //get file size
-	if err != nil {
-		return nil, err
+	p.rootBox = newRootBox()
+	p.dataBoxs = make(map[string][]dataBox)
+	p.mediaInfo = new(MediaInfo)
+	p.warnings = nil
+
+	if p.size < 0 { //get size of source
+		size, err := sourceSize(p.reader)
+		if err != nil {
+			return nil, err
+		}
+		p.size = size
 	}
 
 	p.rootBox.headerSize = 0
-	p.rootBox.size = uint64(fileInfo.Size())
+	p.rootBox.size = uint64(p.size)
 	p.rootBox.boxType = "root"
 
-	p.file.Seek(0, seekFromStart) //ensure parsing start from file head
-	err = p.parseInnerBox(p.rootBox.Box)
+	if err := p.parseInnerBox(p.rootBox.Box); err != nil {
+		return nil, err
+	}
+
+	if err := rangeBox(p.rootBox.Box, func(b *Box) error {
+		return p.tolerate(p.decodeBox(b))
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := rangeBox(p.rootBox.Box, func(b *Box) error {
+		return p.tolerate(p.scanBoxData(b))
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(p.mediaInfo.tracks) > 0 { //the longest track , with edit lists applied
+		duration := time.Duration(0)
+		for _, t := range p.mediaInfo.tracks {
+			if d := t.PresentationDuration(); d > duration {
+				duration = d
+			}
+		}
+		p.mediaInfo.duration = &duration
+	}
+
+	p.mediaInfo.warnings = p.warnings
+	return p.mediaInfo, nil
+}
 
-	err = rangeBox(p.rootBox.Box, p.scanBoxData) // //TODO:handle err
+//Root return root box of the tree parsed by Parse , nil if not parsed yet
+func (p *Parser) Root() *Box {
+	if p.rootBox.boxType != "root" {
+		return nil
+	}
+	return p.rootBox.Box
+}
 
-	return p.mediaInfo, err
+//Query return boxs matching path in the tree parsed by Parse , see Box.Query
+func (p *Parser) Query(path string) ([]*Box, error) {
+	root := p.Root()
+	if root == nil {
+		return nil, errNotParsed
+	}
+	return root.Query(path)
 }
 
-//parseBoxHeadr parses b's size and type in header, return an error,if any,and resumes file seeker
-func (p *Parser) parseBoxHeadr(h *header) (err error) {
-	savedOffset, err := p.file.Seek(0, seekFromCurrent)
-	if err != nil {
-		return
+//tolerate records err as a warning and return nil in lenient mode , if err is caused by malformed data ,
+//	otherwise return err
+func (p *Parser) tolerate(err error) error {
+	var parseErr *ParseError
+	if !p.lenient || !errors.As(err, &parseErr) || !isMalformed(parseErr.Err) {
+		return err
 	}
-	defer p.file.Seek(savedOffset, seekFromStart)
 
+	p.warnings = append(p.warnings, parseErr)
+	return nil
+}
+
+//parseBoxHeadr parses b's size and type in header at offset , return an error,if any ,
+//	size is not checked
+func (p *Parser) parseBoxHeadr(h *header, offset int64) (err error) {
 	temp := new([8]byte)
 
-	if n, err := p.file.Read(temp[:8]); err != nil || n != 8 {
-		if err == io.EOF {
-			return err
-		}
-		return fmt.Errorf("parseBoxHeadr: %d-th byte  %v\n", n, err)
+	if err = readAt(p.reader, temp[:8], offset); err != nil {
+		return
 	}
 
 	h.boxType = string(temp[4:8])
@@ -77,11 +163,8 @@ func (p *Parser) parseBoxHeadr(h *header) (err error) {
 	size := uint64(binary.BigEndian.Uint32(temp[:4]))
 
 	if size == 1 { //if size == 1 get largeSize in next 8 bytes
-		if n, err := p.file.Read(temp[:8]); err != nil || n != 8 {
-			if err == io.EOF {
-				return err
-			}
-			return fmt.Errorf("parseBoxHeadr: %d-th byte  %v\n", n, err)
+		if err = readAt(p.reader, temp[:8], offset+normalHeaderSize); err != nil {
+			return
 		}
 
 		h.headerSize = largeHeaderSize
@@ -89,8 +172,11 @@ func (p *Parser) parseBoxHeadr(h *header) (err error) {
 
 	}
 
-	if size <= 0 {
-		panic(fmt.Errorf("parseBoxHeadr: size <=0 : %v \t temp:%v", h, temp))
+	if h.boxType == "uuid" { //extended type follows
+		if err = readAt(p.reader, h.userType[:], offset+int64(h.headerSize)); err != nil {
+			return
+		}
+		h.headerSize += len(h.userType)
 	}
 
 	h.size = size
@@ -98,28 +184,80 @@ func (p *Parser) parseBoxHeadr(h *header) (err error) {
 	return
 }
 
-//parseInnerBox parses b's inner boxs , return an error,if any,and resumes file seeker
-func (p *Parser) parseInnerBox(b *Box) (err error) {
-	savedOffset, err := p.file.Seek(0, seekFromCurrent)
-	defer p.file.Seek(savedOffset, seekFromStart)
-	var endOffset, offsetTmp int64
+//innerBoxsOffset return offset of the first inner box of container b , return an error,if any
+func (p *Parser) innerBoxsOffset(b *Box) (int64, error) {
+	offset := b.offset + int64(b.headerSize) //skip  box header
+	prefix := containers[b.boxType]
+	if b.isSampleEntry() {
+		size, err := sampleEntrySize(b, p.reader)
+		if err != nil {
+			return 0, err
+		}
+		prefix = size
+	}
+
+	switch b.boxType {
+	case "meta": //QuickTime meta is not a full box , hdlr follows header directly
+		temp := new([8]byte)
+		if err := readAt(p.reader, temp[:], offset); err != nil {
+			return 0, err
+		}
+		if string(temp[4:8]) == "hdlr" {
+			prefix = 0
+		}
+	case "iinf":
+		version := new([1]byte)
+		if err := readAt(p.reader, version[:], offset); err != nil {
+			return 0, err
+		}
+		if version[0] != 0 {
+			prefix = 8
+		}
+	}
+
+	return offset + int64(prefix), nil
+}
 
-	offsetTmp, err = p.file.Seek(int64(b.headerSize), seekFromCurrent) //skip  box header
+//parseInnerBox parses b's inner boxs , return an error,if any
+func (p *Parser) parseInnerBox(b *Box) (err error) {
+	offset, err := p.innerBoxsOffset(b)
 	if err != nil {
-		return
+		return p.tolerate(newParseError(b, err))
 	}
+	endOffset := b.offset + int64(b.size)
 
-	endOffset = savedOffset + int64(b.size-uint64(b.headerSize))
+	for offset < endOffset {
+		if endOffset-offset == 4 && p.isTerminator(offset) { //QuickTime may end list of boxs with 4 zero bytes
+			return nil
+		}
 
-	for {
 		innerBox := newBox()
 		innerBox.nth = b.nth + 1
-		innerBox.offset = offsetTmp
-		if err = p.parseBoxHeadr(innerBox.header); err != nil {
-			if err == io.EOF {
+		innerBox.offset = offset
+		innerBox.parent = b
+		if err = p.parseBoxHeadr(innerBox.header, offset); err != nil {
+			return p.tolerate(newParseError(innerBox, err)) //can not find next box , skip the rest
+		}
+
+		if innerBox.size == 0 { //extends to the end of file
+			if b.parent != nil {
+				if err = p.tolerate(newParseError(innerBox, ErrBadSize)); err != nil {
+					return
+				}
+			}
+			innerBox.size = uint64(endOffset - offset)
+		} else if innerBox.size < uint64(innerBox.headerSize) {
+			return p.tolerate(newParseError(innerBox, ErrBadSize))
+		}
+
+		if innerBox.size > uint64(endOffset-offset) { //overruns b
+			if err = p.tolerate(newParseError(innerBox, ErrBadSize)); err != nil {
 				return
 			}
-			return fmt.Errorf("parseInnerBox:%v %v\n", b, err)
+			innerBox.size = uint64(endOffset - offset)
+		}
+		if innerBox.size < uint64(innerBox.headerSize) { //clamped into its header , skip the rest
+			return p.tolerate(newParseError(innerBox, ErrBadSize))
 		}
 		b.addInnerBox(innerBox)
 
@@ -131,85 +269,106 @@ func (p *Parser) parseInnerBox(b *Box) (err error) {
 
 		}
 
-		if offsetTmp, err = p.file.Seek(int64(innerBox.size), seekFromCurrent); err != nil || offsetTmp >= endOffset {
-			return
-		}
+		offset += int64(innerBox.size)
 	}
 
+	return nil
+}
+
+//isTerminator reports whether there are 4 zero bytes at offset
+func (p *Parser) isTerminator(offset int64) bool {
+	temp := new([4]byte)
+	return readAt(p.reader, temp[:], offset) == nil && binary.BigEndian.Uint32(temp[:]) == 0
 }
 
-//rangeBox run func do for each box contained below b and return an error , if any
+//rangeBox run func do for each box contained below b in file order and return an error , if any
 func rangeBox(b *Box, do func(*Box) error) (err error) {
-	for _, innerBoxSlice := range b.innerBoxs {
-		for _, inner := range innerBoxSlice {
-
-			err = do(inner)
-			if err != nil {
-				return err
-			}
+	for _, inner := range b.innerBoxs {
 
-			// if inner.isContainer() {
-			err = rangeBox(inner, do)
-			if err != nil {
-				return err
-			}
-			// }
+		err = do(inner)
+		if err != nil {
+			return err
+		}
 
+		err = rangeBox(inner, do)
+		if err != nil {
+			return err
 		}
+
 	}
 	return nil
 }
 
-//scanBoxData scans box data from file, return an error , if any
-func (p *Parser) scanBoxData(b *Box) (err error) {
-	// savedOffset, _ := p.file.Seek(0, seekFromCurrent)
-	// defer p.file.Seek(savedOffset, seekFromStart)
+//decodeBox decodes b's data by the decoder registered for its type and attaches the result to b ,
+//	return a ParseError , if any
+func (p *Parser) decodeBox(b *Box) error {
+	decode := lookupDecoder(b)
+	if decode == nil {
+		return nil
+	}
+
+	payload, err := decode(b, p.reader)
+	if err != nil {
+		return newParseError(b, err)
+	}
+	b.payload = payload
+
+	if d, ok := payload.(dataBox); ok {
+		p.dataBoxs[b.boxType] = append(p.dataBoxs[b.boxType], d)
+	}
+
+	return nil
+}
 
+//scanBoxData collects media information from decoded box , return an error , if any
+func (p *Parser) scanBoxData(b *Box) (err error) {
 	switch b.boxType {
 
 	case "trak": //get track data
 
-		hdlrBox := newHDLR(b.innerBoxs["mdia"][0].innerBoxs["hdlr"][0])
-		err = hdlrBox.scan(p.file)
-		p.currentTrack = hdlrBox.handlerType //update parsing track type
+		track, err := newTrack(b)
+		if err != nil || track == nil {
+			return err
+		}
+		track.reader = p.reader
+		track.size = p.size
+		p.currentTrack = track.handlerType //update parsing track type
+		p.mediaInfo.tracks = append(p.mediaInfo.tracks, track)
 
 		if p.currentTrack == "vide" {
-			tkhdBox := newTKHD(b.innerBoxs["tkhd"][0])
-			err = tkhdBox.scan(p.file)
-
-			p.mediaInfo.height = tkhdBox.height
-			p.mediaInfo.width = tkhdBox.width
+			if len(p.rootBox.videoTracks) == 0 { //the first video track
+				p.mediaInfo.height = track.height
+				p.mediaInfo.width = track.width
+			}
 			p.rootBox.videoTracks = append(p.rootBox.videoTracks, newTRAK(b))
 		} else if p.currentTrack == "soun" {
-			mdhdBox := newMDHD(b.innerBoxs["mdia"][0].innerBoxs["mdhd"][0])
-			mdhdBox.scan(p.file)
-
-			p.mediaInfo.soundSamplingRate = mdhdBox.timeScale
+			if len(p.rootBox.soundTracks) == 0 { //the first sound track
+				p.mediaInfo.soundSamplingRate = track.SampleRate()
+			}
 			p.rootBox.soundTracks = append(p.rootBox.soundTracks, newTRAK(b))
 		}
 
 	case "mvhd":
-		mvhdBox := newMVHD(b)
-		err = mvhdBox.scan(p.file)
+		mvhdBox, ok := b.payload.(*mvhd)
+		if !ok {
+			return nil
+		}
+		if mvhdBox.timeScale == 0 {
+			return newParseError(b, ErrInvalidData)
+		}
 
-		duration, _ := time.ParseDuration(fmt.Sprintf("%ds", mvhdBox.duration/mvhdBox.timeScale))
+		duration := toDuration(mvhdBox.duration, mvhdBox.timeScale)
 		p.mediaInfo.duration = &duration
+		p.mediaInfo.timeScale = mvhdBox.timeScale
+		p.mediaInfo.rawDuration = mvhdBox.duration
 		p.mediaInfo.creationTime = mvhdBox.creationTime
 		p.mediaInfo.modifTime = mvhdBox.modifTime
 
-		p.dataBoxs[b.boxType] = append(p.dataBoxs[b.boxType], mvhdBox)
-	case "stsc":
-		stscBox := newSTSC(b)
-		err = stscBox.scan(p.file)
-		p.dataBoxs[b.boxType] = append(p.dataBoxs[b.boxType], stscBox)
-
-	case "stco":
-		stcoBox := newSTCO(b)
-		err = stcoBox.scan(p.file)
-		p.dataBoxs[b.boxType] = append(p.dataBoxs[b.boxType], stcoBox)
-
-		// default:
-		// 	return fmt.Errorf("unexcepted box type:%v", b.boxType)
+	case "mehd": //duration of fragmented movie , in case of that in mvhd is 0
+		mehdBox, ok := b.payload.(*mehd)
+		if ok && p.mediaInfo.rawDuration == 0 {
+			p.mediaInfo.rawDuration = mehdBox.fragmentDuration
+		}
 	}
 	return
 }
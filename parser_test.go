@@ -1,8 +1,13 @@
 package mp4parser
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
 	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,7 +20,7 @@ func init() {
 
 func TestNewParser(t *testing.T) {
 	p := NewParser(testFile)
-	if p.file == nil || p.rootBox == nil || p.dataBoxs == nil || p.mediaInfo == nil {
+	if p.reader == nil || p.rootBox == nil || p.dataBoxs == nil || p.mediaInfo == nil {
 		t.Errorf("got %#v", p)
 	}
 }
@@ -33,7 +38,7 @@ func TestParse(t *testing.T) {
 	t2 := time.Date(2010, 3, 20, 21, 29, 12, 0, time.UTC)
 	want.modifTime = &t2
 
-	t3, _ := time.ParseDuration("5s")
+	t3, _ := time.ParseDuration("5.568s")
 	want.duration = &t3
 
 	p := NewParser(testFile)
@@ -50,9 +55,299 @@ func TestParse(t *testing.T) {
 
 }
 
+func TestParseReaderAt(t *testing.T) {
+	data, err := ioutil.ReadFile(`./sample/sample.mp4`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		name string
+		p    *Parser
+	}{
+		{"bytes.Reader", NewReaderAtParser(bytes.NewReader(data), -1)},
+		{"bytes.Reader with size", NewReaderAtParser(bytes.NewReader(data), int64(len(data)))},
+		{"io.SectionReader", NewReaderAtParser(io.NewSectionReader(testFile, 0, int64(len(data))), -1)},
+		{"io.ReadSeeker", NewReadSeekerParser(bytes.NewReader(data))},
+	}
+
+	for _, test := range tests {
+		got, err := test.p.Parse()
+		if err != nil {
+			t.Errorf("%s: got error %v", test.name, err)
+			continue
+		}
+		if got.String() != want.String() {
+			t.Errorf("%s: want:\n%v\ngot:\n%v", test.name, want, got)
+		}
+	}
+}
+
+func TestParseUnknownSize(t *testing.T) {
+	var r struct{ io.ReaderAt }
+	r.ReaderAt = testFile
+
+	if _, err := NewReaderAtParser(r, -1).Parse(); err != errUnknownSize {
+		t.Errorf("want %v , got %v", errUnknownSize, err)
+	}
+}
+
 func BenchmarkParse(b *testing.B) {
 	p := NewParser(testFile)
 	for i := 0; i < b.N; i++ {
 		p.Parse()
 	}
 }
+
+//mkMVHD return a version 0 mvhd box
+func mkMVHD(timeScale, duration uint32) []byte {
+	return mkFullBox("mvhd", 0, 0, be(uint32(0), uint32(0), timeScale, duration), make([]byte, 80))
+}
+
+//mkTKHD return a version 0 tkhd box , width and height are integers
+func mkTKHD(trackID, duration uint32, width, height uint16) []byte {
+	return mkFullBox("tkhd", 0, trackEnabled|trackInMovie,
+		be(uint32(0), uint32(0), trackID, uint32(0), duration), make([]byte, 52),
+		be(uint32(width)<<16, uint32(height)<<16))
+}
+
+//mkMDHD return a version 0 mdhd box
+func mkMDHD(timeScale, duration uint32) []byte {
+	return mkFullBox("mdhd", 0, 0, be(uint32(0), uint32(0), timeScale, duration, uint16(0x55c4), uint16(0)))
+}
+
+//mkHDLR return a hdlr box
+func mkHDLR(handlerType, name string) []byte {
+	return mkFullBox("hdlr", 0, 0, make([]byte, 4), []byte(handlerType), make([]byte, 12), []byte(name+"\000"))
+}
+
+//mkTrak return a trak box of handlerType , stbl contains stblBoxs
+func mkTrak(trackID uint32, handlerType string, stblBoxs ...[]byte) []byte {
+	return mkBox("trak",
+		mkTKHD(trackID, 1000, 640, 480),
+		mkBox("mdia",
+			mkMDHD(48000, 48000),
+			mkHDLR(handlerType, handlerType+" handler"),
+			mkBox("minf", mkBox("stbl", stblBoxs...))))
+}
+
+func TestParseLenient(t *testing.T) {
+	data, err := ioutil.ReadFile(`./sample/sample.mp4`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mvhd := mkMVHD(1000, 1000)
+	overrunMoov := mkBox("moov", mvhd, mkTrak(1, "vide"))
+	overrunMoov[3] += 100
+	//frma overruns trak , and is clamped into its header
+	clampedMoov := mkBox("moov", []byte{0, 0, 0, 14, 't', 'r', 'a', 'k', 0, 0, 1, 0, 'f', 'r'}, mvhd)
+
+	tests := [...]struct {
+		name         string
+		data         []byte
+		wantWarnings []error
+		wantInfo     bool //want the same info as sample
+	}{
+		{"trailing garbage", append(data[:len(data):len(data)], 1, 2, 3), []error{ErrTruncated}, true},
+		{"garbage header", append(data[:len(data):len(data)], 0, 0, 0, 3, 'a', 'b', 'c', 'd'), []error{ErrBadSize}, true},
+		{"overrun last box", append(data[:len(data):len(data)], 0, 0, 1, 0, 'f', 'r', 'e', 'e', 0), []error{ErrBadSize}, true},
+		{"zero size last box", append(data[:len(data):len(data)], 0, 0, 0, 0, 'f', 'r', 'e', 'e', 0), nil, true},
+		{"zero size inner box", mkBox("moov", mvhd, []byte{0, 0, 0, 0, 'f', 'r', 'e', 'e', 0}), []error{ErrBadSize}, false},
+		{"overrun file", overrunMoov, []error{ErrBadSize}, false},
+		{"clamped into header", clampedMoov, []error{ErrBadSize, ErrBadSize, ErrMissingBox}, false},
+		{"missing box", mkBox("moov", mvhd, mkBox("trak", mkTKHD(1, 0, 0, 0)), mkBox("trak", mkTKHD(2, 0, 0, 0))),
+			[]error{ErrMissingBox, ErrMissingBox}, false},
+	}
+
+	for _, test := range tests {
+		_, err := NewReaderAtParser(bytes.NewReader(test.data), -1).Parse()
+		if (err != nil) != (len(test.wantWarnings) > 0) {
+			t.Errorf("%s: strict mode got error %v", test.name, err)
+		}
+
+		got, err := NewReaderAtParser(bytes.NewReader(test.data), -1, Lenient()).Parse()
+		if err != nil {
+			t.Errorf("%s: lenient mode got error %v", test.name, err)
+			continue
+		}
+		if len(got.Warnings()) != len(test.wantWarnings) {
+			t.Errorf("%s: want warnings %v , got %v", test.name, test.wantWarnings, got.Warnings())
+			continue
+		}
+		for i, warning := range got.Warnings() {
+			if !errors.Is(warning, test.wantWarnings[i]) {
+				t.Errorf("%s: want warnings %v , got %v", test.name, test.wantWarnings, got.Warnings())
+			}
+		}
+		if test.wantInfo && got.String() != want.String() {
+			t.Errorf("%s: want:\n%v\ngot:\n%v", test.name, want, got)
+		}
+	}
+}
+
+func TestCreationTimeMissing(t *testing.T) {
+	//mvhd is truncated and dropped in lenient mode
+	data := mkBox("moov", mkFullBox("mvhd", 0, 0, be(uint32(0))))
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1, Lenient()).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.CreationTime(); !got.IsZero() {
+		t.Errorf("want zero creation time , got %v", got)
+	}
+}
+
+func TestParseUndersizedBox(t *testing.T) {
+	//fields of undersized box are not read from its sibling
+	free := mkBox("free", make([]byte, 100))
+	mvhd := mkMVHD(1000, 1000)
+	tests := [...]struct {
+		name string
+		data []byte
+	}{
+		{"mvhd", mkBox("moov", mkFullBox("mvhd", 0, 0), free)},
+		{"mvhd version 1", mkBox("moov", mkFullBox("mvhd", 1, 0, make([]byte, 20)), free)},
+		{"tkhd", mkBox("moov", mvhd, mkBox("trak", mkFullBox("tkhd", 0, 0, make([]byte, 20)), free))},
+		{"mdhd", mkBox("moov", mvhd, mkBox("trak", mkTKHD(1, 0, 0, 0), mkBox("mdia", mkFullBox("mdhd", 0, 0), free)))},
+		{"hdlr", mkBox("moov", mvhd, mkBox("trak", mkTKHD(1, 0, 0, 0), mkBox("mdia", mkFullBox("hdlr", 0, 0), free)))},
+	}
+	for _, test := range tests {
+		_, err := NewReaderAtParser(bytes.NewReader(test.data), -1).Parse()
+		var perr *ParseError
+		if !errors.Is(err, ErrTruncated) || !errors.As(err, &perr) || perr.BoxType != test.name[:4] {
+			t.Errorf("%s: want %v , got %v", test.name, ErrTruncated, err)
+		}
+	}
+}
+
+func TestParseBoxOrder(t *testing.T) {
+	p := NewParser(testFile)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		b    *Box
+		want []string
+	}{
+		{p.rootBox.Box, []string{"ftyp", "free", "mdat", "moov", "free"}},
+		{p.rootBox.innerBoxs[3], []string{"mvhd", "iods", "trak", "trak", "udta"}},
+	}
+	for _, test := range tests {
+		got := make([]string, 0, len(test.b.innerBoxs))
+		for _, inner := range test.b.innerBoxs {
+			got = append(got, inner.boxType)
+		}
+		if strings.Join(got, " ") != strings.Join(test.want, " ") {
+			t.Errorf("inner boxs of %s,want %v , got %v", test.b.boxType, test.want, got)
+		}
+	}
+
+	dump := p.rootBox.String()
+	for i := 0; i < 10; i++ {
+		if _, err := p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		if got := p.rootBox.String(); got != dump {
+			t.Fatalf("String() is not deterministic,want:\n%s\ngot:\n%s", dump, got)
+		}
+	}
+}
+
+func TestParseContainers(t *testing.T) {
+	ilst := mkBox("ilst",
+		mkBox("\xa9nam", mkFullBox("data", 0, 1, make([]byte, 4), []byte("title"))),
+		mkBox("----", mkFullBox("mean", 0, 0, []byte("com.apple.iTunes")), mkFullBox("name", 0, 0, []byte("x"))))
+	data := bytes.Join([][]byte{
+		mkBox("moov",
+			mkMVHD(1000, 1000),
+			mkBox("udta",
+				mkFullBox("meta", 0, 0, mkHDLR("mdir", ""), ilst),
+				[]byte{0, 0, 0, 0})), //QuickTime terminator
+		mkBox("meta", mkHDLR("mdta", ""), mkBox("keys")), //QuickTime meta
+		mkFullBox("meta", 0, 0, mkHDLR("pict", ""),
+			mkFullBox("iinf", 0, 0, be(uint16(1)), mkFullBox("infe", 2, 0, make([]byte, 8))),
+			mkBox("iprp", mkBox("ipco", mkFullBox("ispe", 0, 0, be(uint32(64), uint32(64)))))),
+		mkFullBox("meta", 0, 0, mkFullBox("iinf", 1, 0, be(uint32(0)))),
+	}, nil)
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := [...]string{
+		"moov/udta/meta/hdlr",
+		"moov/udta/meta/ilst/\xa9nam/data",
+		"moov/udta/meta/ilst/----/name",
+		"meta[0]/keys",
+		"meta[1]/iinf/infe",
+		"meta[1]/iprp/ipco/ispe",
+		"meta[2]/iinf",
+	}
+	for _, path := range paths {
+		if boxs, err := p.Query(path); err != nil || len(boxs) != 1 {
+			t.Errorf("Query(%q),got %v , %v", path, boxs, err)
+		}
+	}
+
+	p = NewParser(testFile)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range [...]string{"moov/trak[0]/mdia/minf/stbl/stsd/avc1", "moov/trak[1]/mdia/minf/dinf/dref/url ", "moov/udta/meta/hdlr"} {
+		if boxs, err := p.Query(path); err != nil || len(boxs) != 1 {
+			t.Errorf("Query(%q),got %v , %v", path, boxs, err)
+		}
+	}
+}
+
+func TestParseVersion1(t *testing.T) {
+	const (
+		timeScale = 1000000
+		duration  = 36000000000 //10 hours , overflows 32 bits
+		seconds   = 4323456000  //2041-01-01 00:00:00 UTC since 1904 , overflows 32 bits
+	)
+
+	mvhd := mkFullBox("mvhd", 1, 0, be(uint64(seconds), uint64(seconds+1), uint32(timeScale), uint64(duration)), make([]byte, 80))
+	tkhdData := mkFullBox("tkhd", 1, trackEnabled,
+		be(uint64(seconds), uint64(seconds), uint32(7), uint32(0), uint64(duration)), make([]byte, 52),
+		be(uint32(1920)<<16, uint32(1080)<<16))
+	mdhdData := mkFullBox("mdhd", 1, 0, be(uint64(seconds), uint64(seconds), uint32(90000), uint64(duration/timeScale*90000), uint16(0), uint16(0)))
+	data := mkBox("moov", mvhd, mkBox("trak", tkhdData, mkBox("mdia", mdhdData, mkHDLR("vide", ""))))
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	info, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *info.Duration() != 10*time.Hour || info.TimeScale() != timeScale || info.RawDuration() != duration {
+		t.Errorf("want duration 10h , got %v (%d/%d)", info.Duration(), info.RawDuration(), info.TimeScale())
+	}
+	if want := time.Date(2041, 1, 1, 0, 0, 1, 0, time.UTC); !info.ModifiedTime().Equal(want) {
+		t.Errorf("want modified time %v , got %v", want, info.ModifiedTime())
+	}
+	if info.Width() != 1920 || info.Height() != 1080 {
+		t.Errorf("want 1920x1080 , got %vx%v", info.Width(), info.Height())
+	}
+
+	tkhdBoxs, _ := p.Query("moov/trak/tkhd")
+	if got := tkhdBoxs[0].Payload().(*tkhd); got.trackID != 7 || got.duration != duration || got.flags != trackEnabled {
+		t.Errorf("tkhd,got track id %d , duration %d , flags %x", got.trackID, got.duration, got.flags)
+	}
+	mdhdBoxs, _ := p.Query("moov/trak/mdia/mdhd")
+	if got := mdhdBoxs[0].Payload().(*mdhd); got.timeScale != 90000 || got.duration != duration/timeScale*90000 {
+		t.Errorf("mdhd,got time scale %d , duration %d", got.timeScale, got.duration)
+	}
+}
@@ -0,0 +1,83 @@
+package mp4parser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//pathSegment one segment of query path , e.g. trak[1]
+type pathSegment struct {
+	boxType string //"*" matches any type
+	index   int    //-1 matches all
+}
+
+//parsePath splits path into segments , return an error if path is invalid
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(path, "/")
+	segments := make([]pathSegment, 0, len(parts))
+	for _, part := range parts {
+		seg := pathSegment{boxType: part, index: -1}
+
+		if i := strings.IndexByte(part, '['); i >= 0 && strings.HasSuffix(part, "]") {
+			seg.boxType = part[:i]
+			if index := part[i+1 : len(part)-1]; index != "*" {
+				n, err := strconv.Atoi(index)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("mp4parser: invalid index %q in path %q", index, path)
+				}
+				seg.index = n
+			}
+		}
+
+		if seg.boxType == "" {
+			return nil, fmt.Errorf("mp4parser: empty box type in path %q", path)
+		}
+		segments = append(segments, seg)
+	}
+
+	return segments, nil
+}
+
+//Query return boxs below b matching path in file order ,
+//	path is box types separated by '/' , e.g. moov/trak[*]/mdia/minf/stbl/stsd ,
+//	a type may be followed by [n] to match the n-th (from 0) box of that type or [*] to match all ,
+//	type without index matches all boxs of that type , type "*" matches any type
+func (b *Box) Query(path string) ([]*Box, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []*Box{b}
+	for _, seg := range segments {
+		next := make([]*Box, 0, len(matched))
+		for _, m := range matched {
+			next = append(next, m.match(seg)...)
+		}
+		matched = next
+	}
+
+	return matched, nil
+}
+
+//match return inner boxs of b matching seg
+func (b *Box) match(seg pathSegment) []*Box {
+	candidates := b.innerBoxs
+	if seg.boxType != "*" {
+		candidates = b.typeIndex[seg.boxType]
+	}
+
+	if seg.index < 0 {
+		return candidates
+	}
+	if seg.index < len(candidates) {
+		return candidates[seg.index : seg.index+1]
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package mp4parser
+
+import (
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := [...]struct {
+		path    string
+		want    []pathSegment
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"/moov/", []pathSegment{{"moov", -1}}, false},
+		{"moov/trak[1]/*", []pathSegment{{"moov", -1}, {"trak", 1}, {"*", -1}}, false},
+		{"moov/trak[*]/mdia", []pathSegment{{"moov", -1}, {"trak", -1}, {"mdia", -1}}, false},
+		{"dinf/url ", []pathSegment{{"dinf", -1}, {"url ", -1}}, false},
+		{"moov//trak", nil, true},
+		{"moov/trak[-1]", nil, true},
+		{"moov/trak[a]", nil, true},
+		{"[1]", nil, true},
+	}
+
+	for _, test := range tests {
+		got, err := parsePath(test.path)
+		if (err != nil) != test.wantErr {
+			t.Errorf("path %q,want error %t , got %v", test.path, test.wantErr, err)
+			continue
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("path %q,want %v , got %v", test.path, test.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("path %q,want %v , got %v", test.path, test.want, got)
+			}
+		}
+	}
+}
+
+func TestQuery(t *testing.T) {
+	p := NewParser(testFile)
+	if _, err := p.Query("moov"); err == nil {
+		t.Error("want error before parsing")
+	}
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		path        string
+		wantOffsets []int64
+	}{
+		{"moov", []int64{380040}},
+		{"free", []int64{28, 383499}},
+		{"free[1]", []int64{383499}},
+		{"free[2]", nil},
+		{"moov/trak[*]/mdia/minf/stbl/stsd", []int64{380425, 381967}},
+		{"moov/trak[1]/mdia/hdlr", []int64{381866}},
+		{"moov/*/mdia/mdhd", []int64{380288, 381834}},
+		{"moov/udta", []int64{383388}},
+		{"moov/trak/udta", []int64{383366}},
+		{"nothing/here", nil},
+	}
+
+	for _, test := range tests {
+		got, err := p.Query(test.path)
+		if err != nil {
+			t.Errorf("path %q,got error %v", test.path, err)
+			continue
+		}
+		if len(got) != len(test.wantOffsets) {
+			t.Errorf("path %q,want %v , got %v", test.path, test.wantOffsets, got)
+			continue
+		}
+		for i, b := range got {
+			if b.Offset() != test.wantOffsets[i] {
+				t.Errorf("path %q,want %v , got %d-th at %d", test.path, test.wantOffsets, i, b.Offset())
+			}
+		}
+	}
+}
+
+func TestQueryPathRoundTrip(t *testing.T) {
+	p := NewParser(testFile)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	root := p.Root()
+	if root.Parent() != nil || root.Type() != "root" {
+		t.Fatalf("got root %v", root)
+	}
+
+	rangeBox(root, func(b *Box) error {
+		got, err := root.Query(b.Path())
+		if err != nil || len(got) != 1 || got[0] != b {
+			t.Errorf("Query(%q),want %v , got %v , %v", b.Path(), b.header, got, err)
+		}
+		if b.Parent().Offset() > b.Offset() || b.Offset()+int64(b.Size()) > b.Parent().Offset()+int64(b.Parent().Size()) {
+			t.Errorf("box %s is out of parent", b.Path())
+		}
+		if b.HeaderSize() != normalHeaderSize {
+			t.Errorf("box %s,got header size %d", b.Path(), b.HeaderSize())
+		}
+		return nil
+	})
+}
@@ -0,0 +1,58 @@
+package mp4parser
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+//errUnknownSize is returned when size of source can not be determined
+var errUnknownSize = errors.New("mp4parser: unknown size of source")
+
+//readSeekerAt adapts io.ReadSeeker to io.ReaderAt by seeking before every read
+type readSeekerAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+//ReadAt implements io.ReaderAt
+func (r *readSeekerAt) ReadAt(p []byte, off int64) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err = r.rs.Seek(off, seekFromStart); err != nil {
+		return
+	}
+	return io.ReadFull(r.rs, p)
+}
+
+//size return the size of data in underlying io.ReadSeeker
+func (r *readSeekerAt) size() (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rs.Seek(0, seekFromEnd)
+}
+
+//sourceSize return the size of data in r , return an error ,if any
+//	r should implement Stat() like *os.File, or Size() like *bytes.Reader and *io.SectionReader
+func sourceSize(r io.ReaderAt) (int64, error) {
+	switch s := r.(type) {
+	case interface {
+		Stat() (os.FileInfo, error)
+	}:
+		fileInfo, err := s.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return fileInfo.Size(), nil
+	case interface {
+		Size() int64
+	}:
+		return s.Size(), nil
+	case *readSeekerAt:
+		return s.size()
+	}
+	return 0, errUnknownSize
+}
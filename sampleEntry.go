@@ -0,0 +1,612 @@
+package mp4parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	visualSampleEntrySize  = 78 //size of fields between header and inner boxs
+	audioSampleEntrySize   = 28
+	audioSampleEntryV1Size = 44 //QuickTime sound description version 1
+	audioSampleEntryV2Size = 64 //QuickTime sound description version 2
+)
+
+//visualSampleEntries are types of visual sample entry
+var visualSampleEntries = map[string]bool{
+	"avc1": true, "avc2": true, "avc3": true, "avc4": true,
+	"hvc1": true, "hev1": true, "dvh1": true, "dvhe": true, "dva1": true, "dvav": true,
+	"av01": true, "dav1": true, "vp08": true, "vp09": true,
+	"mp4v": true, "s263": true, "encv": true,
+}
+
+//audioSampleEntries are types of audio sample entry
+var audioSampleEntries = map[string]bool{
+	"mp4a": true, "enca": true, "Opus": true, "ac-3": true, "ec-3": true, "ac-4": true,
+	"fLaC": true, "alac": true, "ipcm": true, "fpcm": true, ".mp3": true, "samr": true, "sawb": true,
+	"lpcm": true, "sowt": true, "twos": true, "in24": true, "in32": true, "fl32": true, "fl64": true,
+	"raw ": true, "ulaw": true, "alaw": true,
+}
+
+//videoConfigTypes are types of codec configuration box in visual sample entry
+var videoConfigTypes = [...]string{"avcC", "hvcC", "av1C", "vpcC", "esds"}
+
+//audioConfigTypes are types of codec configuration box in audio sample entry
+var audioConfigTypes = [...]string{"esds", "dOps", "dac3", "dec3", "dac4", "dfLa", "alac", "pcmC"}
+
+//configParsers parse data of codec configuration box and other boxs in sample entry by type , nil keeps data only
+var configParsers = map[string]func([]byte) (interface{}, error){
+	"dac4": nil,
+	"avcC": parseAVCConfig,
+	"hvcC": parseHEVCConfig,
+	"av1C": parseAV1Config,
+	"vpcC": parseVPConfig,
+	"esds": parseESDS,
+	"dOps": parseOpusConfig,
+	"dac3": parseAC3Config,
+	"dec3": parseEAC3Config,
+	"dfLa": parseFLACConfig,
+	"alac": parseALACConfig,
+	"pcmC": parsePCMConfig,
+	"colr": parseColourInfo,
+	"pasp": parsePixelAspectRatio,
+	"clap": parseCleanAperture,
+	"mdcv": parseMasteringDisplay,
+	"clli": parseContentLightLevel,
+	"dvcC": parseDolbyVisionConfig,
+	"dvvC": parseDolbyVisionConfig,
+	"dvwC": parseDolbyVisionConfig,
+}
+
+func init() {
+	for boxType := range visualSampleEntries {
+		registerDataBox(boxType, func(b *Box) dataBox { return newVisualSampleEntry(b) })
+	}
+	for boxType := range audioSampleEntries {
+		if _, ok := configParsers[boxType]; ok { //alac , both sample entry and configuration box
+			continue
+		}
+		registerDataBox(boxType, func(b *Box) dataBox { return newAudioSampleEntry(b) })
+	}
+	for boxType, parse := range configParsers {
+		boxType, parse := boxType, parse
+		registerDataBox(boxType, func(b *Box) dataBox {
+			if b.isSampleEntry() {
+				return newAudioSampleEntry(b)
+			}
+			return newCodecConfig(b, parse)
+		})
+	}
+	registerDataBox("frma", func(b *Box) dataBox { return newRawData(b) })
+	registerDataBox("srat", func(b *Box) dataBox { return newRawData(b) })
+}
+
+//isSampleEntry reports whether b is a known sample entry in stsd
+func (b *Box) isSampleEntry() bool {
+	return b.parent != nil && b.parent.boxType == "stsd" &&
+		(visualSampleEntries[b.boxType] || audioSampleEntries[b.boxType])
+}
+
+//sampleEntrySize return size of fields between header and inner boxs of sample entry b , return an error ,if any
+func sampleEntrySize(b *Box, r io.ReaderAt) (int, error) {
+	if visualSampleEntries[b.boxType] {
+		return visualSampleEntrySize, nil
+	}
+
+	version, err := soundDescriptionVersion(b, r)
+	switch version {
+	case 1:
+		return audioSampleEntryV1Size, err
+	case 2:
+		return audioSampleEntryV2Size, err
+	}
+	return audioSampleEntrySize, err
+}
+
+//soundDescriptionVersion return version of QuickTime sound description of audio sample entry b ,
+//	0 for ISO audio sample entry , whose version 1 is only allowed in stsd of version 1
+func soundDescriptionVersion(b *Box, r io.ReaderAt) (uint16, error) {
+	temp := new([2]byte)
+	if err := readAt(r, temp[:], b.offset+int64(b.headerSize)+8); err != nil {
+		return 0, err
+	}
+	version := binary.BigEndian.Uint16(temp[:])
+	if version == 0 || version > 2 {
+		return 0, nil
+	}
+
+	stsdVersion := new([1]byte)
+	if err := readAt(r, stsdVersion[:], b.parent.offset+int64(b.parent.headerSize)); err != nil {
+		return 0, err
+	}
+	if stsdVersion[0] != 0 {
+		return 0, nil
+	}
+
+	return version, nil
+}
+
+//VideoInfo contains information of visual sample entry
+type VideoInfo struct {
+	Codec           string  //four-character code of sample entry , original format if encrypted , e.g. "avc1"
+	Encrypted       bool    //sample entry is "encv"
+	Width           uint16  //in pixels
+	Height          uint16  //in pixels
+	HorizResolution float64 //pixels per inch
+	VertResolution  float64 //pixels per inch
+	FrameCount      uint16  //frames per sample
+	CompressorName  string
+	Depth           uint16 //0x0018 for color without alpha
+
+	ConfigType string //type of codec configuration box , e.g. "avcC"
+	Config     []byte //data of codec configuration box , following its header
+
+	AVC  *AVCConfig //one of following is set by ConfigType
+	HEVC *HEVCConfig
+	AV1  *AV1Config
+	VP   *VPConfig
+
+	Colour            *ColourInfo //from boxs following codec configuration , nil if not present
+	ICCProfile        []byte
+	PixelAspect       *PixelAspectRatio
+	CleanAperture     *CleanAperture
+	MasteringDisplay  *MasteringDisplay
+	ContentLightLevel *ContentLightLevel
+	DolbyVision       *DolbyVisionConfig
+}
+
+//CodecString return RFC 6381 codec string , e.g. "avc1.64001F" , or Codec if not known
+func (info *VideoInfo) CodecString() string {
+	switch {
+	case info.AVC != nil && strings.HasPrefix(info.Codec, "avc"):
+		return fmt.Sprintf("%s.%02X%02X%02X", info.Codec, info.AVC.Profile, info.AVC.ProfileCompatibility, info.AVC.Level)
+	case info.HEVC != nil && (info.Codec == "hvc1" || info.Codec == "hev1"):
+		return info.HEVC.codecString(info.Codec)
+	case info.AV1 != nil && info.Codec == "av01":
+		tier := 'M'
+		if info.AV1.HighTier {
+			tier = 'H'
+		}
+		return fmt.Sprintf("av01.%d.%02d%c.%02d", info.AV1.Profile, info.AV1.Level, tier, info.AV1.BitDepth)
+	case info.VP != nil && (info.Codec == "vp08" || info.Codec == "vp09"):
+		return fmt.Sprintf("%s.%02d.%02d.%02d", info.Codec, info.VP.Profile, info.VP.Level, info.VP.BitDepth)
+	case info.DolbyVision != nil && (strings.HasPrefix(info.Codec, "dv") || info.Codec == "dav1"):
+		return fmt.Sprintf("%s.%02d.%02d", info.Codec, info.DolbyVision.Profile, info.DolbyVision.Level)
+	}
+	return info.Codec
+}
+
+//BitDepth return bit depth of luma from codec configuration , 8 if not known
+func (info *VideoInfo) BitDepth() uint8 {
+	switch {
+	case info.AVC != nil && info.AVC.SPSInfo != nil:
+		return info.AVC.SPSInfo.BitDepthLuma
+	case info.HEVC != nil:
+		return info.HEVC.BitDepthLuma
+	case info.AV1 != nil:
+		return info.AV1.BitDepth
+	case info.VP != nil:
+		return info.VP.BitDepth
+	}
+	return 8
+}
+
+//HDR reports whether transfer characteristics in colr box or codec configuration is of HDR ,
+//	or the track is of Dolby Vision
+func (info *VideoInfo) HDR() bool {
+	switch {
+	case info.DolbyVision != nil:
+		return true
+	case info.Colour != nil:
+		return info.Colour.HDR()
+	case info.AVC != nil && info.AVC.SPSInfo != nil:
+		return info.AVC.SPSInfo.HDR()
+	case info.HEVC != nil && info.HEVC.SPSInfo != nil:
+		return info.HEVC.SPSInfo.HDR()
+	case info.AV1 != nil && info.AV1.SequenceHeader != nil:
+		return info.AV1.SequenceHeader.HDR()
+	case info.VP != nil:
+		return info.VP.HDR()
+	}
+	return false
+}
+
+//DisplayAspectRatio return aspect ratio of displayed picture , of clean aperture or coded size from SPS ,
+//	scaled by pixel aspect ratio from pasp box or SPS , 0 if size is unknown
+func (info *VideoInfo) DisplayAspectRatio() float64 {
+	width, height := float64(info.Width), float64(info.Height)
+	hSpacing, vSpacing := float64(1), float64(1)
+
+	var vui *VUI
+	switch {
+	case info.AVC != nil && info.AVC.SPSInfo != nil:
+		width, height = float64(info.AVC.SPSInfo.Width), float64(info.AVC.SPSInfo.Height)
+		vui = &info.AVC.SPSInfo.VUI
+	case info.HEVC != nil && info.HEVC.SPSInfo != nil:
+		width, height = float64(info.HEVC.SPSInfo.Width), float64(info.HEVC.SPSInfo.Height)
+		vui = &info.HEVC.SPSInfo.VUI
+	}
+	if c := info.CleanAperture; c != nil && c.Width() > 0 && c.Height() > 0 {
+		width, height = c.Width(), c.Height()
+	}
+
+	if p := info.PixelAspect; p != nil && p.HSpacing != 0 && p.VSpacing != 0 {
+		hSpacing, vSpacing = float64(p.HSpacing), float64(p.VSpacing)
+	} else if vui != nil && vui.SARWidth != 0 && vui.SARHeight != 0 {
+		hSpacing, vSpacing = float64(vui.SARWidth), float64(vui.SARHeight)
+	}
+
+	if height == 0 {
+		return 0
+	}
+	return width * hSpacing / (height * vSpacing)
+}
+
+//AudioInfo contains information of audio sample entry and its codec configuration
+type AudioInfo struct {
+	Codec        string //four-character code of sample entry , original format if encrypted , e.g. "mp4a"
+	Encrypted    bool   //sample entry is "enca"
+	ChannelCount uint16
+	SampleSize   uint16 //in bits
+	SampleRate   uint32 //in Hz
+
+	ConfigType string //type of codec configuration box , e.g. "dOps"
+	Config     []byte //data of codec configuration box , following its header
+
+	ES   *ESDescriptor //one of following is set by ConfigType
+	Opus *OpusConfig
+	AC3  *AC3Config
+	EAC3 *EAC3Config
+	FLAC *FLACStreamInfo
+	ALAC *ALACConfig
+	PCM  *PCMConfig
+}
+
+//visual sample entry
+/**
+*header					normalHeaderSize/largeHeaderSize
+*_reserved				6
+*data_reference_index	2
+*pre_defined			2
+*_reserved				2
+*pre_defined			12
+*width					2
+*height					2
+*horizresolution		4	//[16.16] , 0x00480000 = 72 dpi
+*vertresolution			4	//[16.16]
+*_reserved				4
+*frame_count			2
+*compressorname			32	//pascal string
+*depth					2
+*pre_defined			2
+*inner boxs					//codec configuration , pasp , colr , etc.
+ */
+type visualSampleEntry struct {
+	*Box
+	dataRefIndex uint16
+	info         VideoInfo
+}
+
+func newVisualSampleEntry(b *Box) *visualSampleEntry {
+	return &visualSampleEntry{
+		Box: b,
+	}
+}
+
+//scan visual sample entry data in r , return an error ,if any
+func (b *visualSampleEntry) scan(r io.ReaderAt) (err error) {
+	temp := new([visualSampleEntrySize]byte)
+
+	if b.dataSize() < visualSampleEntrySize {
+		return ErrTruncated
+	}
+	err = readAt(r, temp[:], b.offset+int64(b.headerSize))
+	if err != nil {
+		return
+	}
+
+	b.dataRefIndex = binary.BigEndian.Uint16(temp[6:8])
+	b.info.Codec = b.boxType
+	b.info.Width = binary.BigEndian.Uint16(temp[24:26])
+	b.info.Height = binary.BigEndian.Uint16(temp[26:28])
+	b.info.HorizResolution = fixed32ToF(binary.BigEndian.Uint32(temp[28:32]))
+	b.info.VertResolution = fixed32ToF(binary.BigEndian.Uint32(temp[32:36]))
+	b.info.FrameCount = binary.BigEndian.Uint16(temp[40:42])
+
+	name := temp[42:74]
+	if n := int(name[0]); n < len(name) {
+		name = name[1 : n+1]
+	}
+	if i := bytes.IndexByte(name, '\000'); i >= 0 {
+		name = name[:i]
+	}
+	b.info.CompressorName = string(name)
+
+	b.info.Depth = binary.BigEndian.Uint16(temp[74:76])
+
+	return
+}
+
+//collect fills information from decoded inner boxs
+func (b *visualSampleEntry) collect() {
+	if config := b.findConfig(videoConfigTypes[:]); config != nil {
+		b.info.ConfigType = config.boxType
+		b.info.Config = config.data
+
+		switch c := config.parsed.(type) {
+		case *AVCConfig:
+			b.info.AVC = c
+		case *HEVCConfig:
+			b.info.HEVC = c
+		case *AV1Config:
+			b.info.AV1 = c
+		case *VPConfig:
+			b.info.VP = c
+		}
+	}
+
+	for _, inner := range b.innerBoxs { //the first one of each type is taken
+		data, ok := inner.payload.(*codecConfig)
+		if !ok {
+			continue
+		}
+		switch p := data.parsed.(type) {
+		case *ColourInfo:
+			if b.info.Colour == nil {
+				b.info.Colour = p
+			}
+		case []byte:
+			if inner.boxType == "colr" && b.info.ICCProfile == nil {
+				b.info.ICCProfile = p
+			}
+		case *PixelAspectRatio:
+			if b.info.PixelAspect == nil {
+				b.info.PixelAspect = p
+			}
+		case *CleanAperture:
+			if b.info.CleanAperture == nil {
+				b.info.CleanAperture = p
+			}
+		case *MasteringDisplay:
+			if b.info.MasteringDisplay == nil {
+				b.info.MasteringDisplay = p
+			}
+		case *ContentLightLevel:
+			if b.info.ContentLightLevel == nil {
+				b.info.ContentLightLevel = p
+			}
+		case *DolbyVisionConfig:
+			if b.info.DolbyVision == nil {
+				b.info.DolbyVision = p
+			}
+		}
+	}
+
+	if b.boxType == "encv" {
+		b.info.Encrypted = true
+		b.info.Codec = b.originalFormat()
+	}
+}
+
+//findConfig return the first decoded codec configuration box of types in sample entry b , nil if not found
+func (b *Box) findConfig(types []string) *codecConfig {
+	for _, boxType := range types {
+		if config, err := b.findBox(boxType); err == nil {
+			if data, ok := config.payload.(*codecConfig); ok {
+				return data
+			}
+		}
+	}
+	return nil
+}
+
+//originalFormat return original format of encrypted sample entry b , found in sinf/frma , or b's type if not found
+func (b *Box) originalFormat() string {
+	if frma, err := b.findBox("sinf", "frma"); err == nil {
+		if data, ok := frma.payload.(*rawData); ok && len(data.data) == 4 {
+			return string(data.data)
+		}
+	}
+	return b.boxType
+}
+
+//CodecString return RFC 6381 codec string , e.g. "mp4a.40.2" , "opus" , or Codec if not known
+func (info *AudioInfo) CodecString() string {
+	switch info.Codec {
+	case "mp4a":
+		if info.ES != nil {
+			return info.ES.CodecString()
+		}
+	case "Opus":
+		return "opus"
+	case "fLaC":
+		return "flac"
+	}
+	return info.Codec
+}
+
+//audio sample entry
+/**
+*header					normalHeaderSize/largeHeaderSize
+*_reserved				6
+*data_reference_index	2
+*version				2	//QuickTime sound description version , 0 in ISO
+*revision				2
+*vendor					4
+*channelcount			2
+*samplesize				2
+*compression_id			2
+*packet_size			2
+*samplerate				4	//[16.16]
+*							//QuickTime version 1
+*samples_per_packet		4
+*bytes_per_packet		4
+*bytes_per_frame		4
+*bytes_per_sample		4
+*							//QuickTime version 2 , instead of version 1 fields
+*size_of_struct_only	4
+*audio_sample_rate		8	//float64
+*num_audio_channels		4
+*always_7F000000		4
+*const_bits_per_channel	4
+*format_specific_flags	4
+*const_bytes_per_packet	4
+*const_frames_per_packet	4
+*inner boxs					//codec configuration , sinf , etc.
+ */
+type audioSampleEntry struct {
+	*Box
+	dataRefIndex uint16
+	version      uint16
+	info         AudioInfo
+}
+
+func newAudioSampleEntry(b *Box) *audioSampleEntry {
+	return &audioSampleEntry{
+		Box: b,
+	}
+}
+
+//scan audio sample entry data in r , return an error ,if any
+func (b *audioSampleEntry) scan(r io.ReaderAt) (err error) {
+	temp := new([audioSampleEntryV2Size]byte)
+
+	if b.dataSize() < audioSampleEntrySize {
+		return ErrTruncated
+	}
+	if b.version, err = soundDescriptionVersion(b.Box, r); err != nil {
+		return
+	}
+	size, err := sampleEntrySize(b.Box, r)
+	if err != nil {
+		return
+	}
+	if b.dataSize() < int64(size) {
+		return ErrTruncated
+	}
+	if err = readAt(r, temp[:size], b.offset+int64(b.headerSize)); err != nil {
+		return
+	}
+
+	b.dataRefIndex = binary.BigEndian.Uint16(temp[6:8])
+	b.info.Codec = b.boxType
+	b.info.ChannelCount = binary.BigEndian.Uint16(temp[16:18])
+	b.info.SampleSize = binary.BigEndian.Uint16(temp[18:20])
+	b.info.SampleRate = binary.BigEndian.Uint32(temp[24:28]) >> 16
+
+	if b.version == 2 {
+		b.info.SampleRate = uint32(math.Float64frombits(binary.BigEndian.Uint64(temp[32:40])) + 0.5)
+		b.info.ChannelCount = uint16(binary.BigEndian.Uint32(temp[40:44]))
+		b.info.SampleSize = uint16(binary.BigEndian.Uint32(temp[48:52]))
+	}
+
+	return
+}
+
+//collect fills information from decoded inner boxs
+func (b *audioSampleEntry) collect() {
+	if b.boxType == "enca" {
+		b.info.Encrypted = true
+		b.info.Codec = b.originalFormat()
+	}
+
+	if srat, err := b.findBox("srat"); err == nil { //sample rate of ISO audio sample entry version 1
+		if data, ok := srat.payload.(*rawData); ok && len(data.data) == 8 {
+			b.info.SampleRate = binary.BigEndian.Uint32(data.data[4:8])
+		}
+	}
+
+	config := b.findConfig(audioConfigTypes[:])
+	if config == nil {
+		return
+	}
+	b.info.ConfigType = config.boxType
+	b.info.Config = config.data
+
+	switch c := config.parsed.(type) {
+	case *ESDescriptor:
+		b.info.ES = c
+		if c.AudioConfig != nil {
+			if rate := c.AudioConfig.OutputSampleRate(); rate != 0 {
+				b.info.SampleRate = rate
+			}
+			if channels := c.AudioConfig.ChannelCount(); channels != 0 {
+				b.info.ChannelCount = channels
+			}
+		}
+	case *OpusConfig:
+		b.info.Opus = c
+		b.info.ChannelCount = uint16(c.OutputChannelCount)
+	case *AC3Config:
+		b.info.AC3 = c
+		b.info.SampleRate = c.SampleRate()
+		b.info.ChannelCount = c.ChannelCount()
+	case *EAC3Config:
+		b.info.EAC3 = c
+		b.info.SampleRate = c.SampleRate()
+		b.info.ChannelCount = c.ChannelCount()
+	case *FLACStreamInfo:
+		b.info.FLAC = c
+		b.info.SampleRate = c.SampleRate
+		b.info.ChannelCount = uint16(c.ChannelCount)
+		b.info.SampleSize = uint16(c.BitsPerSample)
+	case *ALACConfig:
+		b.info.ALAC = c
+		b.info.SampleRate = c.SampleRate
+		b.info.ChannelCount = uint16(c.NumChannels)
+		b.info.SampleSize = uint16(c.BitDepth)
+	case *PCMConfig:
+		b.info.PCM = c
+		b.info.SampleSize = uint16(c.SampleSize)
+	}
+}
+
+//codecConfig keeps data of codec configuration box following its header , and the parsed result
+type codecConfig struct {
+	*Box
+	data   []byte
+	parse  func([]byte) (interface{}, error)
+	parsed interface{} //nil if parse is nil
+}
+
+func newCodecConfig(b *Box, parse func([]byte) (interface{}, error)) *codecConfig {
+	return &codecConfig{
+		Box:   b,
+		parse: parse,
+	}
+}
+
+//scan codec configuration box data in r , return an error ,if any
+func (b *codecConfig) scan(r io.ReaderAt) (err error) {
+	b.data = make([]byte, b.dataSize())
+	if err = readAt(r, b.data, b.offset+int64(b.headerSize)); err != nil {
+		return
+	}
+
+	if b.parse != nil {
+		b.parsed, err = b.parse(b.data)
+	}
+	return
+}
+
+//rawData keeps data of box following its header
+type rawData struct {
+	*Box
+	data []byte
+}
+
+func newRawData(b *Box) *rawData {
+	return &rawData{
+		Box: b,
+	}
+}
+
+//scan box data in r , return an error ,if any
+func (b *rawData) scan(r io.ReaderAt) (err error) {
+	b.data = make([]byte, b.dataSize())
+	return readAt(r, b.data, b.offset+int64(b.headerSize))
+}
@@ -0,0 +1,196 @@
+package mp4parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+//mkVisualSampleEntry return a visual sample entry of boxType containing inner boxs
+func mkVisualSampleEntry(boxType string, width, height uint16, compressorName string, inner ...[]byte) []byte {
+	name := make([]byte, 32)
+	name[0] = byte(len(compressorName))
+	copy(name[1:], compressorName)
+
+	fields := bytes.Join([][]byte{
+		make([]byte, 6), be(uint16(1)), make([]byte, 16),
+		be(width, height, uint32(0x00480000), uint32(0x00480000), uint32(0), uint16(1)),
+		name, be(uint16(0x18), int16(-1)),
+	}, nil)
+	return mkBox(boxType, append([][]byte{fields}, inner...)...)
+}
+
+//mkSTSD return a stsd box containing sample entries
+func mkSTSD(entries ...[]byte) []byte {
+	return mkFullBox("stsd", 0, 0, append([][]byte{be(uint32(len(entries)))}, entries...)...)
+}
+
+func TestVisualSampleEntry(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	video := info.Tracks()[0].Video()
+	if video == nil {
+		t.Fatal("want video info of the first track")
+	}
+	if video.Codec != "avc1" || video.Width != 560 || video.Height != 320 ||
+		video.HorizResolution != 72 || video.VertResolution != 72 || video.FrameCount != 1 ||
+		video.CompressorName != "JVT/AVC Coding" || video.Depth != 0x18 ||
+		video.ConfigType != "avcC" || len(video.Config) != 43 || video.Encrypted {
+		t.Errorf("got %+v", video)
+	}
+	if info.Tracks()[1].Video() != nil {
+		t.Errorf("sound track,got video info %+v", info.Tracks()[1].Video())
+	}
+
+	hvcC := mkBox("hvcC", mkHVCC(testMain10SPS))
+	encv := mkVisualSampleEntry("encv", 3840, 2160, "", hvcC,
+		mkBox("sinf", mkBox("frma", []byte("hvc1")), mkFullBox("schm", 0, 0, []byte("cenc"), be(uint32(0x10000)))))
+	data := mkBox("moov", mkMVHD(1000, 1000),
+		mkTrak(1, "vide", mkSTSD(encv, mkVisualSampleEntry("vp09", 1280, 720, "", mkFullBox("vpcC", 1, 0, []byte{0, 10, 0x82, 1, 1, 1, 0, 0})))))
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	info, err = p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	track := info.Tracks()[0]
+	video = track.Video()
+	if track.Codec() != "hvc1" || video.Codec != "hvc1" || !video.Encrypted ||
+		video.Width != 3840 || video.Height != 2160 || video.CompressorName != "" ||
+		video.ConfigType != "hvcC" || !bytes.Equal(video.Config, mkHVCC(testMain10SPS)) ||
+		video.HEVC == nil || video.BitDepth() != 10 || !video.HDR() || track.CodecString() != "hvc1.2.4.L153.B0" {
+		t.Errorf("got %+v", video)
+	}
+
+	for _, path := range [...]string{
+		"moov/trak/mdia/minf/stbl/stsd/encv/sinf/schm",
+		"moov/trak/mdia/minf/stbl/stsd/vp09/vpcC",
+	} {
+		if boxs, _ := p.Query(path); len(boxs) != 1 {
+			t.Errorf("Query(%q),got %v", path, boxs)
+		}
+	}
+}
+
+func TestVisualSampleEntryUndersized(t *testing.T) {
+	//fields of short entry are not read from the following box
+	stsd := mkSTSD(mkBox("avc1", make([]byte, 20)), mkBox("free", make([]byte, 100)))
+	data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide", stsd))
+	_, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	var perr *ParseError
+	if !errors.Is(err, ErrTruncated) || !errors.As(err, &perr) || perr.BoxType != "avc1" {
+		t.Errorf("want %v , got %v", ErrTruncated, err)
+	}
+}
+
+//mkAudioSampleEntry return an audio sample entry of boxType containing inner boxs
+func mkAudioSampleEntry(boxType string, channelCount, sampleSize uint16, sampleRate uint32, inner ...[]byte) []byte {
+	fields := bytes.Join([][]byte{
+		make([]byte, 6), be(uint16(1)), make([]byte, 8),
+		be(channelCount, sampleSize, uint16(0), uint16(0), sampleRate<<16),
+	}, nil)
+	return mkBox(boxType, append([][]byte{fields}, inner...)...)
+}
+
+func TestAudioSampleEntry(t *testing.T) {
+	p := NewParser(testFile)
+	info, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audio := info.Tracks()[1].Audio()
+	if audio == nil {
+		t.Fatal("want audio info of the second track")
+	}
+	if audio.Codec != "mp4a" || audio.ChannelCount != 1 || audio.SampleSize != 16 ||
+		audio.SampleRate != 48000 || audio.ConfigType != "esds" || len(audio.Config) == 0 || audio.Encrypted {
+		t.Errorf("got %+v", audio)
+	}
+	if info.Tracks()[0].Audio() != nil {
+		t.Errorf("video track,got audio info %+v", info.Tracks()[0].Audio())
+	}
+	if boxs, _ := p.Query("moov/trak[1]/mdia/minf/stbl/stsd/mp4a/esds"); len(boxs) != 1 {
+		t.Errorf("want esds inside mp4a , got %v", boxs)
+	}
+
+	//QuickTime sound description version 1 and 2 , only in stsd of version 0
+	qtV1 := mkBox("sowt", make([]byte, 6), be(uint16(1), uint16(1), uint16(0), uint32(0)),
+		be(uint16(2), uint16(16), uint16(0xfffe), uint16(0), uint32(44100<<16)),
+		be(uint32(1), uint32(2), uint32(4), uint32(2)), mkBox("chan", make([]byte, 12)))
+	qtV2 := mkBox("lpcm", make([]byte, 6), be(uint16(1), uint16(2), uint16(0), uint32(0)),
+		be(uint16(3), uint16(16), uint16(0xfffe), uint16(0), uint32(1<<16)),
+		be(uint32(72), uint64(0x40F7700000000000), uint32(6), uint32(0x7F000000), uint32(24), uint32(0xc), uint32(18), uint32(1)),
+		mkBox("chan", make([]byte, 12)))
+
+	opus := mkAudioSampleEntry("Opus", 2, 16, 48000,
+		mkBox("dOps", []byte{0, 6}, be(uint16(312), uint32(44100), int16(0)), []byte{1, 4, 2, 0, 4, 1, 2, 3, 5}))
+	//44100 , 3/2 , lfe
+	ac3 := mkAudioSampleEntry("ac-3", 2, 16, 48000, mkBox("dac3", []byte{0x50, 0x3c, 0x40}))
+	//48000 , 3/2 , lfe
+	ec3 := mkAudioSampleEntry("ec-3", 2, 16, 48000, mkBox("dec3", []byte{0x0c, 0x00, 0x20, 0x0f, 0x00}))
+	flac := mkAudioSampleEntry("fLaC", 2, 16, 44100, mkFullBox("dfLa", 0, 0,
+		[]byte{0x80, 0, 0, 34}, be(uint16(4096), uint16(4096)), make([]byte, 6),
+		[]byte{0x17, 0x70, 0x0b, 0x70}, make([]byte, 4), make([]byte, 16))) //96000 , 6 channels , 24 bits
+	alac := mkAudioSampleEntry("alac", 2, 16, 44100, mkFullBox("alac", 0, 0,
+		be(uint32(4096)), []byte{0, 24, 40, 10, 14, 2}, be(uint16(255), uint32(0), uint32(0), uint32(88200))))
+	ipcm := mkAudioSampleEntry("ipcm", 1, 32, 16000, mkFullBox("pcmC", 0, 0, []byte{1, 24}))
+	enca := mkAudioSampleEntry("enca", 2, 16, 44100, mkESDS(0x40, []byte{0x12, 0x10}),
+		mkBox("sinf", mkBox("frma", []byte("mp4a"))))
+
+	tests := [...]struct {
+		entry                    []byte
+		codec, configType        string
+		channelCount, sampleSize uint16
+		sampleRate               uint32
+	}{
+		{qtV1, "sowt", "", 2, 16, 44100},
+		{qtV2, "lpcm", "", 6, 24, 96000},
+		{opus, "Opus", "dOps", 6, 16, 48000},
+		{ac3, "ac-3", "dac3", 6, 16, 44100},
+		{ec3, "ec-3", "dec3", 6, 16, 48000},
+		{flac, "fLaC", "dfLa", 6, 24, 96000},
+		{alac, "alac", "alac", 2, 24, 88200},
+		{ipcm, "ipcm", "pcmC", 1, 24, 16000},
+		{enca, "mp4a", "esds", 2, 16, 44100},
+	}
+	for _, test := range tests {
+		data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "soun", mkSTSD(test.entry)))
+		info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+		if err != nil {
+			t.Errorf("%s: %v", test.codec, err)
+			continue
+		}
+
+		audio := info.Tracks()[0].Audio()
+		if audio == nil || audio.Codec != test.codec || audio.ConfigType != test.configType ||
+			audio.ChannelCount != test.channelCount || audio.SampleSize != test.sampleSize ||
+			audio.SampleRate != test.sampleRate {
+			t.Errorf("%s: got %+v", test.codec, audio)
+			continue
+		}
+		if info.SamplingRate() != test.sampleRate {
+			t.Errorf("%s: SamplingRate,want %d , got %d", test.codec, test.sampleRate, info.SamplingRate())
+		}
+	}
+}
+
+func TestAudioSampleEntryUndersized(t *testing.T) {
+	free := mkBox("free", make([]byte, 100))
+	for _, entry := range [...][]byte{
+		mkBox("mp4a", make([]byte, 20)),
+		mkBox("sowt", make([]byte, 6), be(uint16(1), uint16(1)), make([]byte, 20)), //version 1 of 44 bytes
+		mkBox("lpcm", make([]byte, 6), be(uint16(1), uint16(2)), make([]byte, 36)), //version 2 of 64 bytes
+	} {
+		data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "soun", mkSTSD(entry, free)))
+		_, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+		var perr *ParseError
+		if !errors.Is(err, ErrTruncated) || !errors.As(err, &perr) || perr.BoxType != string(entry[4:8]) {
+			t.Errorf("%s: want %v , got %v", entry[4:8], ErrTruncated, err)
+		}
+	}
+}
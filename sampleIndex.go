@@ -0,0 +1,269 @@
+package mp4parser
+
+import (
+	"fmt"
+)
+
+//Sample describes a sample of track , joined from tables in stbl
+type Sample struct {
+	Number           uint32 //1-based , as in stss
+	Offset           int64  //absolute offset in file
+	Size             uint32
+	DTS              int64 //decoding time in time scale of media
+	PTS              int64 //presentation time , DTS plus offset in ctts
+	Duration         uint32
+	Sync             bool   //random access point , e.g. key frame
+	DescriptionIndex uint32 //1-based index of sample entry in stsd
+}
+
+//Samples return all samples of t in decoding order , the index is built on first call ,
+//	return an error if tables in stbl are missing or inconsistent
+func (t *Track) Samples() ([]Sample, error) {
+	if t.samples == nil {
+		samples, err := t.buildSamples()
+		if err != nil {
+			return nil, err
+		}
+		t.samples = samples
+	}
+	return t.samples, nil
+}
+
+//buildSamples join stsz , stsc , stco , stts , ctts and stss of t , followed by samples in fragments
+func (t *Track) buildSamples() ([]Sample, error) {
+	stblBox, err := t.box.findBox("mdia", "minf", "stbl")
+	if err != nil {
+		return nil, err
+	}
+
+	sizeTable, sizeBox, err := stblTable(stblBox, "stsz", "stz2")
+	if err != nil {
+		return nil, err
+	}
+	sizes, ok := sizeTable.(*stsz)
+	if !ok {
+		return nil, newParseError(sizeBox, ErrInvalidData)
+	}
+	if sizes.entryCount == 0 && uint64(sizes.sampleCount)*uint64(sizes.size(0)) > t.fileSize() { //of constant size , not bounded by data of stsz
+		return nil, newParseError(sizeBox, fmt.Errorf("%w: %d samples overrun file", ErrInvalidData, sizes.sampleCount))
+	}
+	if sizes.sampleCount > 0 {
+		if err = checkSampleCount(stblBox, sizes.sampleCount); err != nil {
+			return nil, err
+		}
+	}
+	samples := make([]Sample, sizes.sampleCount)
+	if len(samples) > 0 {
+		if err = joinSampleTable(stblBox, sizes, samples); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.fragmented() {
+		dts := int64(0)
+		if n := len(samples); n > 0 {
+			dts = samples[n-1].DTS + int64(samples[n-1].Duration)
+		}
+		fragmentSamples, err := t.fragmentSamples(uint32(len(samples))+1, dts)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, fragmentSamples...)
+	}
+
+	return samples, nil
+}
+
+//fileSize return size of source , that of root box , as samples are indexed before t.size is set
+func (t *Track) fileSize() uint64 {
+	b := t.box
+	for b.parent != nil {
+		b = b.parent
+	}
+	return b.size
+}
+
+//checkSampleCount return an error if count of samples in stsz differs from that in stts ,
+//	or exceeds what chunks in stsc and stco/co64 hold , before samples are allocated by it
+func checkSampleCount(stblBox *Box, sampleCount uint32) error {
+	sttsTable, sttsBox, err := stblTable(stblBox, "stts")
+	if err != nil {
+		return err
+	}
+	sttsData, ok := sttsTable.(*stts)
+	if !ok {
+		return newParseError(sttsBox, ErrInvalidData)
+	}
+	total := uint64(0)
+	for _, entry := range sttsData.entrys {
+		total += uint64(entry.sampleCount)
+	}
+	if total != uint64(sampleCount) {
+		return newParseError(sttsBox, fmt.Errorf("%w: stts holds %d of %d samples", ErrInvalidData, total, sampleCount))
+	}
+
+	stscTable, stscBox, err := stblTable(stblBox, "stsc")
+	if err != nil {
+		return err
+	}
+	stcoTable, stcoBox, err := stblTable(stblBox, "stco", "co64")
+	if err != nil {
+		return err
+	}
+	stscData, ok := stscTable.(*stsc)
+	if !ok {
+		return newParseError(stscBox, ErrInvalidData)
+	}
+	stcoData, ok := stcoTable.(*stco)
+	if !ok {
+		return newParseError(stcoBox, ErrInvalidData)
+	}
+	chunkCount := uint32(len(stcoData.chunkOffset))
+	capacity := uint64(0)
+	for i, entry := range stscData.entrys {
+		lastChunk := chunkCount
+		if i+1 < len(stscData.entrys) {
+			lastChunk = stscData.entrys[i+1].firstChunk - 1
+		}
+		if entry.firstChunk > 0 && entry.firstChunk <= lastChunk && lastChunk <= chunkCount { //others are reported when joined
+			capacity += uint64(lastChunk-entry.firstChunk+1) * uint64(entry.samplesPerChunk)
+		}
+	}
+	if capacity < uint64(sampleCount) {
+		return newParseError(stcoBox, fmt.Errorf("%w: chunks hold %d of %d samples", ErrInvalidData, capacity, sampleCount))
+	}
+	return nil
+}
+
+//joinSampleTable set samples by sizes and other tables in stbl
+func joinSampleTable(stblBox *Box, sizes *stsz, samples []Sample) error {
+	for i := range samples {
+		samples[i].Number = uint32(i) + 1
+		samples[i].Size = sizes.size(uint32(i))
+		samples[i].Sync = true
+	}
+
+	if err := stblSampleToChunk(stblBox, samples); err != nil {
+		return err
+	}
+	if err := stblTimeToSample(stblBox, samples); err != nil {
+		return err
+	}
+
+	if cttsBox, err := stblBox.findBox("ctts"); err == nil {
+		if cttsData, ok := cttsBox.payload.(*ctts); ok {
+			n := 0
+			for _, entry := range cttsData.entrys {
+				for i := uint32(0); i < entry.sampleCount && n < len(samples); i++ {
+					samples[n].PTS = samples[n].DTS + entry.sampleOffset
+					n++
+				}
+			}
+		}
+	}
+
+	if stssBox, err := stblBox.findBox("stss"); err == nil {
+		if stssData, ok := stssBox.payload.(*stss); ok {
+			for i := range samples {
+				samples[i].Sync = false
+			}
+			for _, number := range stssData.sampleNumber {
+				if number == 0 || number > uint32(len(samples)) {
+					return newParseError(stssBox, fmt.Errorf("%w: sample number %d out of range", ErrInvalidData, number))
+				}
+				samples[number-1].Sync = true
+			}
+		}
+	}
+
+	return nil
+}
+
+//stblTable return decoded payload and box of the first found type of types in stbl ,
+//	the payload may be of other type if decoder is replaced by RegisterDecoder
+func stblTable(stblBox *Box, types ...string) (interface{}, *Box, error) {
+	for _, boxType := range types {
+		if b, err := stblBox.findBox(boxType); err == nil {
+			if b.payload == nil { //failed to decode in lenient mode
+				return nil, b, newParseError(b, ErrInvalidData)
+			}
+			return b.payload, b, nil
+		}
+	}
+	return nil, nil, newParseError(stblBox, fmt.Errorf("%w: %s", ErrMissingBox, types[0]))
+}
+
+//stblSampleToChunk set offset and description index of samples by stsc and stco/co64 in stbl
+func stblSampleToChunk(stblBox *Box, samples []Sample) error {
+	stscTable, stscBox, err := stblTable(stblBox, "stsc")
+	if err != nil {
+		return err
+	}
+	stcoTable, stcoBox, err := stblTable(stblBox, "stco", "co64")
+	if err != nil {
+		return err
+	}
+	stscData, ok := stscTable.(*stsc)
+	if !ok {
+		return newParseError(stscBox, ErrInvalidData)
+	}
+	stcoData, ok := stcoTable.(*stco)
+	if !ok {
+		return newParseError(stcoBox, ErrInvalidData)
+	}
+	entrys := stscData.entrys
+	chunkOffset := stcoData.chunkOffset
+
+	n := 0
+	for i, entry := range entrys {
+		lastChunk := uint32(len(chunkOffset)) //1-based , inclusive
+		if i+1 < len(entrys) {
+			lastChunk = entrys[i+1].firstChunk - 1
+		}
+		if entry.firstChunk == 0 || lastChunk > uint32(len(chunkOffset)) {
+			return newParseError(stscBox, fmt.Errorf("%w: chunk %d out of range", ErrInvalidData, entry.firstChunk))
+		}
+
+		for chunk := entry.firstChunk; chunk <= lastChunk && n < len(samples); chunk++ {
+			offset := int64(chunkOffset[chunk-1])
+			for j := uint32(0); j < entry.samplesPerChunk && n < len(samples); j++ {
+				samples[n].Offset = offset
+				samples[n].DescriptionIndex = entry.sampleDescIndex
+				offset += int64(samples[n].Size)
+				n++
+			}
+		}
+	}
+	if n < len(samples) {
+		return newParseError(stcoBox, fmt.Errorf("%w: chunks hold %d of %d samples", ErrInvalidData, n, len(samples)))
+	}
+	return nil
+}
+
+//stblTimeToSample set DTS , PTS and duration of samples by stts in stbl
+func stblTimeToSample(stblBox *Box, samples []Sample) error {
+	sttsTable, sttsBox, err := stblTable(stblBox, "stts")
+	if err != nil {
+		return err
+	}
+	sttsData, ok := sttsTable.(*stts)
+	if !ok {
+		return newParseError(sttsBox, ErrInvalidData)
+	}
+
+	n := 0
+	dts := int64(0)
+	for _, entry := range sttsData.entrys {
+		for i := uint32(0); i < entry.sampleCount && n < len(samples); i++ {
+			samples[n].DTS = dts
+			samples[n].PTS = dts
+			samples[n].Duration = entry.sampleDelta
+			dts += int64(entry.sampleDelta)
+			n++
+		}
+	}
+	if n < len(samples) {
+		return newParseError(sttsBox, fmt.Errorf("%w: stts holds %d of %d samples", ErrInvalidData, n, len(samples)))
+	}
+	return nil
+}
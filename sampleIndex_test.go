@@ -0,0 +1,125 @@
+package mp4parser
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSamples(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, err := testFile.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, track := range info.Tracks() {
+		samples, err := track.Samples()
+		if err != nil {
+			t.Fatalf("track %d: %v", track.ID(), err)
+		}
+		if uint32(len(samples)) != track.SampleCount() {
+			t.Errorf("track %d,want %d samples , got %d", track.ID(), track.SampleCount(), len(samples))
+		}
+
+		end := samples[len(samples)-1].DTS + int64(samples[len(samples)-1].Duration)
+		if uint64(end) != track.RawDuration() {
+			t.Errorf("track %d,want duration %d , got %d", track.ID(), track.RawDuration(), end)
+		}
+		for i, s := range samples {
+			if s.Number != uint32(i+1) || s.Size == 0 || s.Offset+int64(s.Size) > stat.Size() || s.DescriptionIndex != 1 ||
+				(i > 0 && s.DTS != samples[i-1].DTS+int64(samples[i-1].Duration)) {
+				t.Errorf("track %d,got sample %+v", track.ID(), s)
+				break
+			}
+		}
+		if !samples[0].Sync {
+			t.Errorf("track %d,want the first sample to be sync sample", track.ID())
+		}
+	}
+
+	again, _ := info.Tracks()[0].Samples()
+	if first, _ := info.Tracks()[0].Samples(); &again[0] != &first[0] {
+		t.Error("want index to be built once")
+	}
+}
+
+func TestSamplesTables(t *testing.T) {
+	stbl := [][]byte{
+		mkFullBox("stsz", 0, 0, be(uint32(0), uint32(5), uint32(10), uint32(20), uint32(30), uint32(40), uint32(50))),
+		mkFullBox("stsc", 0, 0, be(uint32(2), uint32(1), uint32(2), uint32(1), uint32(2), uint32(3), uint32(2))),
+		mkFullBox("stco", 0, 0, be(uint32(2), uint32(1000), uint32(5000))),
+		mkFullBox("stts", 0, 0, be(uint32(2), uint32(4), uint32(100), uint32(1), uint32(200))),
+		mkFullBox("ctts", 0, 0, be(uint32(2), uint32(1), uint32(200), uint32(4), uint32(100))),
+		mkFullBox("stss", 0, 0, be(uint32(2), uint32(1), uint32(4))),
+	}
+	data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide", stbl...))
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := info.Tracks()[0].Samples()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sample{
+		{1, 1000, 10, 0, 200, 100, true, 1},
+		{2, 1010, 20, 100, 200, 100, false, 1},
+		{3, 5000, 30, 200, 300, 100, false, 2},
+		{4, 5030, 40, 300, 400, 100, true, 2},
+		{5, 5070, 50, 400, 500, 200, false, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("want %+v\ngot %+v", want, got)
+	}
+
+	tests := [...]struct {
+		name    string
+		replace int
+		box     []byte
+		want    error
+	}{
+		{"missing stco", 2, mkFullBox("free", 0, 0), ErrMissingBox},
+		{"too few chunks", 2, mkFullBox("stco", 0, 0, be(uint32(1), uint32(1000))), ErrInvalidData},
+		{"too few stts", 3, mkFullBox("stts", 0, 0, be(uint32(1), uint32(4), uint32(100))), ErrInvalidData},
+		{"stss out of range", 5, mkFullBox("stss", 0, 0, be(uint32(1), uint32(6))), ErrInvalidData},
+		{"too many stts", 3, mkFullBox("stts", 0, 0, be(uint32(1), uint32(6), uint32(100))), ErrInvalidData},
+		//constant size of huge count is rejected before samples are allocated
+		{"huge constant stsz", 0, mkFullBox("stsz", 0, 0, be(uint32(10), uint32(0xffffffff))), ErrInvalidData},
+	}
+	for _, test := range tests {
+		boxs := append([][]byte(nil), stbl...)
+		boxs[test.replace] = test.box
+		data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide", boxs...))
+		info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		var perr *ParseError
+		if _, err := info.Tracks()[0].Samples(); !errors.Is(err, test.want) || !errors.As(err, &perr) {
+			t.Errorf("%s: want %v , got %v", test.name, test.want, err)
+		}
+	}
+}
+
+func TestSamplesHugeCount(t *testing.T) {
+	//tables agree on 0xffffffff samples of 10 bytes , far more than the file holds
+	data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide",
+		mkFullBox("stsz", 0, 0, be(uint32(10), uint32(0xffffffff))),
+		mkFullBox("stsc", 0, 0, be(uint32(1), uint32(1), uint32(0xffffffff), uint32(1))),
+		mkFullBox("stco", 0, 0, be(uint32(1), uint32(0))),
+		mkFullBox("stts", 0, 0, be(uint32(1), uint32(0xffffffff), uint32(1)))))
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var perr *ParseError
+	if _, err := info.Tracks()[0].Samples(); !errors.Is(err, ErrInvalidData) || !errors.As(err, &perr) {
+		t.Errorf("want %v , got %v", ErrInvalidData, err)
+	}
+}
@@ -0,0 +1,89 @@
+package mp4parser
+
+import (
+	"errors"
+	"io"
+)
+
+//errSampleRange is returned when reading a sample not in track
+var errSampleRange = errors.New("mp4parser: sample number out of range")
+
+//ReadSample reads data of sample s into buf , which is grown if it is too small ,
+//	return the data , buf[:s.Size] if buf is big enough , and an error , if any ,
+//	ErrInvalidData if s is not in the source
+func (t *Track) ReadSample(s *Sample, buf []byte) ([]byte, error) {
+	if s.Offset < 0 || s.Offset+int64(s.Size) > t.size {
+		return nil, ErrInvalidData
+	}
+	if cap(buf) < int(s.Size) {
+		buf = make([]byte, s.Size)
+	}
+	buf = buf[:s.Size]
+	if err := readAt(t.reader, buf, s.Offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+//SampleReader reads data of samples of a track in decoding order , reusing its buffer ,
+//	it is not safe for concurrent use
+type SampleReader struct {
+	track   *Track
+	samples []Sample
+	next    int //index of sample returned by Next
+	buf     []byte
+}
+
+//NewSampleReader return SampleReader of t , positioned at the first sample ,
+//	return an error if samples of t can't be indexed
+func (t *Track) NewSampleReader() (*SampleReader, error) {
+	samples, err := t.Samples()
+	if err != nil {
+		return nil, err
+	}
+	return &SampleReader{
+		track:   t,
+		samples: samples,
+	}, nil
+}
+
+//Next reads the next sample , return the sample and its data , which is valid until the next call ,
+//	io.EOF if there is no more sample
+func (r *SampleReader) Next() (*Sample, []byte, error) {
+	if r.next >= len(r.samples) {
+		return nil, nil, io.EOF
+	}
+
+	s := &r.samples[r.next]
+	data, err := r.track.ReadSample(s, r.buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.buf = data
+	r.next++
+	return s, data, nil
+}
+
+//ReadSample reads the nth sample , 1-based , return its data , which is valid until the next call ,
+//	the position of Next is not changed
+func (r *SampleReader) ReadSample(n uint32) ([]byte, error) {
+	if n == 0 || n > uint32(len(r.samples)) {
+		return nil, errSampleRange
+	}
+
+	data, err := r.track.ReadSample(&r.samples[n-1], r.buf)
+	if err != nil {
+		return nil, err
+	}
+	r.buf = data
+	return data, nil
+}
+
+//SetPosition makes Next read from the nth sample , 1-based , e.g. Number of SeekPoint found by Track.Seek
+func (r *SampleReader) SetPosition(n uint32) error {
+	if n == 0 || n > uint32(len(r.samples))+1 {
+		return errSampleRange
+	}
+	r.next = int(n) - 1
+	return nil
+}
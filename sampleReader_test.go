@@ -0,0 +1,108 @@
+package mp4parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func TestSampleReader(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, track := range info.Tracks() {
+		r, err := track.NewSampleReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := uint32(0)
+		for {
+			s, data, err := r.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("track %d: %v", track.ID(), err)
+			}
+			n++
+			if s.Number != n || len(data) != int(s.Size) {
+				t.Fatalf("track %d,got sample %+v with %d bytes", track.ID(), s, len(data))
+			}
+			if track.HandlerType() == "vide" && int(binary.BigEndian.Uint32(data[0:4]))+4 > len(data) { //length prefixed NAL units
+				t.Errorf("track %d,sample %d,bad NAL unit length %x", track.ID(), n, data[0:4])
+			}
+		}
+		if n != track.SampleCount() {
+			t.Errorf("track %d,want %d samples , got %d", track.ID(), track.SampleCount(), n)
+		}
+	}
+}
+
+func TestSampleReaderBuffer(t *testing.T) {
+	stbl := [][]byte{
+		mkFullBox("stsz", 0, 0, be(uint32(0), uint32(3), uint32(2), uint32(4), uint32(3))),
+		mkFullBox("stsc", 0, 0, be(uint32(1), uint32(1), uint32(3), uint32(1))),
+		mkFullBox("stco", 0, 0, be(uint32(1), uint32(0))), //offset is set below
+		mkFullBox("stts", 0, 0, be(uint32(1), uint32(3), uint32(1))),
+	}
+	moov := func() []byte { return mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "soun", stbl...)) }
+	stbl[2] = mkFullBox("stco", 0, 0, be(uint32(1), uint32(len(moov())+8)))
+	data := append(moov(), mkBox("mdat", []byte("abcdefghi"))...)
+
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := info.Tracks()[0].NewSampleReader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range [...]string{"ab", "cdef", "ghi"} {
+		if _, got, err := r.Next(); err != nil || string(got) != want {
+			t.Errorf("Next,want %q , got %q , %v", want, got, err)
+		}
+	}
+	if _, _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next,want EOF , got %v", err)
+	}
+
+	if err := r.SetPosition(2); err != nil {
+		t.Fatal(err)
+	}
+	s, got, err := r.Next()
+	if err != nil || s.Number != 2 || string(got) != "cdef" {
+		t.Errorf("Next after SetPosition(2),got %+v %q , %v", s, got, err)
+	}
+	second := &got[0]
+	if got, err = r.ReadSample(1); err != nil || string(got) != "ab" || &got[0] != second {
+		t.Errorf("ReadSample(1),want %q in reused buffer , got %q , %v", "ab", got, err)
+	}
+	for _, n := range [...]uint32{0, 4} {
+		if _, err := r.ReadSample(n); err != errSampleRange {
+			t.Errorf("ReadSample(%d),want %v , got %v", n, errSampleRange, err)
+		}
+	}
+	if err := r.SetPosition(5); err != errSampleRange {
+		t.Errorf("SetPosition(5),want %v , got %v", errSampleRange, err)
+	}
+
+	info, err = NewReaderAtParser(bytes.NewReader(data[:len(data)-1]), -1, Lenient()).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _ = info.Tracks()[0].NewSampleReader()
+	if _, err := r.ReadSample(3); err != ErrInvalidData {
+		t.Errorf("ReadSample(3) of truncated data,want %v , got %v", ErrInvalidData, err)
+	}
+
+	//samples out of source are rejected before buf is allocated
+	for _, s := range [...]Sample{{Offset: -1, Size: 1}, {Offset: 0, Size: 0xffffffff}} {
+		if _, err := info.Tracks()[0].ReadSample(&s, nil); err != ErrInvalidData {
+			t.Errorf("ReadSample(%+v),want %v , got %v", s, ErrInvalidData, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package mp4parser
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+//mkTestBox return Box of header in data , at offset 0
+func mkTestBox(data []byte) *Box {
+	b := newBox()
+	b.size = uint64(len(data))
+	b.headerSize = normalHeaderSize
+	b.boxType = string(data[4:8])
+	return b
+}
+
+func TestSampleTable(t *testing.T) {
+	p := NewParser(testFile)
+	if _, err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	boxs, err := p.Query("moov/trak[0]/mdia/minf/stbl/stts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := uint32(0)
+	for _, e := range boxs[0].Payload().(*stts).entrys {
+		count += e.sampleCount
+	}
+	if count != 166 {
+		t.Errorf("stts,want 166 samples , got %d", count)
+	}
+
+	boxs, err = p.Query("moov/trak[0]/mdia/minf/stbl/stss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boxs[0].Payload().(*stss); got.entryCount == 0 || got.sampleNumber[0] != 1 {
+		t.Errorf("stss,got %v", got.sampleNumber)
+	}
+
+	boxs, err = p.Query("moov/trak[0]/mdia/minf/stbl/sdtp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boxs[0].Payload().(*sdtp); len(got.flags) != 166 {
+		t.Errorf("sdtp,want 166 flags , got %d", len(got.flags))
+	}
+
+	boxs, err = p.Query("moov/trak[0]/mdia/minf/stbl/stsz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := boxs[0].Payload().(*stsz); len(got.sampleSize) != 166 || got.size(165) == 0 || got.size(166) != 0 {
+		t.Errorf("stsz,got %d sizes", len(got.sampleSize))
+	}
+}
+
+func TestCompactSampleTable(t *testing.T) {
+	data := mkBox("moov", mkMVHD(1000, 1000), mkTrak(1, "vide",
+		mkFullBox("stz2", 0, 0, be(uint32(4), uint32(5)), []byte{0x12, 0x3f, 0xa0}),
+		mkFullBox("co64", 0, 0, be(uint32(2), uint64(0x100000000), uint64(8))),
+		mkFullBox("ctts", 1, 0, be(uint32(2), uint32(1), int32(-512), uint32(3), uint32(1024))),
+		mkFullBox("stts", 0, 0, be(uint32(1), uint32(5), uint32(512)))))
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	info, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Tracks()[0].SampleCount(); got != 5 {
+		t.Errorf("want 5 samples , got %d", got)
+	}
+
+	boxs, _ := p.Query("moov/trak/mdia/minf/stbl/stz2")
+	if got := boxs[0].Payload().(*stsz).sampleSize; !reflect.DeepEqual(got, []uint32{1, 2, 3, 15, 10}) {
+		t.Errorf("stz2,got %v", got)
+	}
+	boxs, _ = p.Query("moov/trak/mdia/minf/stbl/co64")
+	if got := boxs[0].Payload().(*stco).chunkOffset; !reflect.DeepEqual(got, []uint64{0x100000000, 8}) {
+		t.Errorf("co64,got %v", got)
+	}
+	boxs, _ = p.Query("moov/trak/mdia/minf/stbl/ctts")
+	if got := boxs[0].Payload().(*ctts).entrys; !reflect.DeepEqual(got, []cttsEntry{{1, -512}, {3, 1024}}) {
+		t.Errorf("ctts,got %v", got)
+	}
+
+	tests := [...]struct {
+		name string
+		box  []byte
+		want []uint32
+	}{
+		{"8 bits", mkFullBox("stz2", 0, 0, be(uint32(8), uint32(2)), []byte{200, 7}), []uint32{200, 7}},
+		{"16 bits", mkFullBox("stz2", 0, 0, be(uint32(16), uint32(2), uint16(40000), uint16(1))), []uint32{40000, 1}},
+	}
+	for _, test := range tests {
+		b := newSTSZ(mkTestBox(test.box))
+		if err := b.scan(bytes.NewReader(test.box)); err != nil || !reflect.DeepEqual(b.sampleSize, test.want) {
+			t.Errorf("%s: want %v , got %v , %v", test.name, test.want, b.sampleSize, err)
+		}
+	}
+
+	for _, box := range [...][]byte{
+		mkFullBox("stz2", 0, 0, be(uint32(12), uint32(1)), []byte{0, 0}),
+		mkFullBox("stz2", 0, 0, be(uint32(16), uint32(3)), []byte{0, 0}),
+	} {
+		b := newSTSZ(mkTestBox(box))
+		if err := b.scan(bytes.NewReader(box)); err == nil {
+			t.Errorf("%x: want error , got nil", box)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package mp4parser
+
+import (
+	"sort"
+	"time"
+)
+
+//SeekPoint a sync sample to start reading at , found by Seek
+type SeekPoint struct {
+	Sample
+	Time time.Duration //presentation time of the sample on timeline of track , negative if it is before the presentation starts
+}
+
+//Seek return the sync sample presented at or before d on timeline of track , edit list applied ,
+//	the first sync sample if d is before all of them ,
+//	return an error if samples of t can't be indexed , ErrNoSyncSample if t has no sync sample
+func (t *Track) Seek(d time.Duration) (*SeekPoint, error) {
+	samples, err := t.Samples()
+	if err != nil {
+		return nil, err
+	}
+	if t.syncSamples == nil {
+		t.syncSamples = make([]int, 0)
+		for i := range samples {
+			if samples[i].Sync {
+				t.syncSamples = append(t.syncSamples, i)
+			}
+		}
+	}
+	if len(t.syncSamples) == 0 {
+		return nil, ErrNoSyncSample
+	}
+
+	target := scaleTime(int64(d), t.timeScale, uint32(time.Second))
+	segment := t.findEditSegment(target)
+	mediaTime := segment.toMedia(target)
+
+	//presentation time of sync samples increases in decoding order
+	i := sort.Search(len(t.syncSamples), func(i int) bool { return samples[t.syncSamples[i]].PTS > mediaTime })
+	if i > 0 {
+		i--
+	}
+
+	s := samples[t.syncSamples[i]]
+	return &SeekPoint{
+		Sample: s,
+		Time:   time.Duration(scaleTime(segment.toTrack(s.PTS), uint32(time.Second), t.timeScale)),
+	}, nil
+}
+
+//editSegment a non-empty edit of track , times are in time scale of media
+type editSegment struct {
+	start     int64 //start on timeline of track
+	duration  int64 //0 if it lasts to the end of media
+	mediaTime int64
+	mediaRate float64 //1 for normal play , 0 for dwell
+}
+
+//toMedia map time on timeline of track to media time , time before the segment is mapped to its start
+func (e *editSegment) toMedia(t int64) int64 {
+	offset := t - e.start
+	if offset < 0 {
+		offset = 0
+	}
+	if e.mediaRate == 1 {
+		return e.mediaTime + offset
+	}
+	return e.mediaTime + int64(float64(offset)*e.mediaRate)
+}
+
+//toTrack map media time to time on timeline of track
+func (e *editSegment) toTrack(mediaTime int64) int64 {
+	switch e.mediaRate {
+	case 0:
+		return e.start
+	case 1:
+		return e.start + mediaTime - e.mediaTime
+	}
+	return e.start + int64(float64(mediaTime-e.mediaTime)/e.mediaRate)
+}
+
+//editSegments return non-empty edits of t in elst , or a segment maps the whole media if there is not any
+func (t *Track) editSegments() []editSegment {
+	movieTimeScale := t.movieTimeScale()
+
+	var segments []editSegment
+	start := int64(0)
+	for _, edit := range t.Edits() {
+		duration := scaleTime(int64(edit.SegmentDuration), t.timeScale, movieTimeScale)
+		if !edit.Empty() {
+			segments = append(segments, editSegment{
+				start:     start,
+				duration:  duration,
+				mediaTime: edit.MediaTime,
+				mediaRate: edit.MediaRate,
+			})
+		}
+		start += duration
+	}
+	if len(segments) == 0 {
+		segments = append(segments, editSegment{start: start, mediaRate: 1})
+	}
+
+	return segments
+}
+
+//findEditSegment return the segment presented at t , the next one if t is in an empty edit ,
+//	the last one if t is after all of them
+func (t *Track) findEditSegment(target int64) *editSegment {
+	segments := t.editSegments()
+	for i := range segments {
+		if target < segments[i].start+segments[i].duration {
+			return &segments[i]
+		}
+	}
+	return &segments[len(segments)-1]
+}
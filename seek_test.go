@@ -0,0 +1,113 @@
+package mp4parser
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeek(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		track  int
+		d      time.Duration
+		number uint32
+		time   time.Duration
+	}{
+		{0, 3 * time.Second, 1, 0}, //the only key frame
+		{0, -time.Second, 1, 0},
+		{1, time.Second, 47, 981333333},
+		{1, time.Hour, 261, 5546666666},
+	}
+	for _, test := range tests {
+		got, err := info.Tracks()[test.track].Seek(test.d)
+		if err != nil {
+			t.Fatalf("track %d , Seek(%v): %v", test.track, test.d, err)
+		}
+		if got.Number != test.number || got.Time != test.time || !got.Sync {
+			t.Errorf("track %d , Seek(%v),want sample %d at %v , got %+v", test.track, test.d, test.number, test.time, got)
+		}
+	}
+}
+
+func TestSeekEditList(t *testing.T) {
+	sizes := make([]interface{}, 0, 12)
+	sizes = append(sizes, uint32(0), uint32(10))
+	for i := 0; i < 10; i++ {
+		sizes = append(sizes, uint32(100))
+	}
+	stbl := [][]byte{
+		mkFullBox("stsz", 0, 0, be(sizes...)),
+		mkFullBox("stsc", 0, 0, be(uint32(1), uint32(1), uint32(10), uint32(1))),
+		mkFullBox("stco", 0, 0, be(uint32(1), uint32(8))),
+		mkFullBox("stts", 0, 0, be(uint32(1), uint32(10), uint32(4800))),
+		mkFullBox("ctts", 0, 0, be(uint32(1), uint32(10), uint32(9600))),
+		mkFullBox("stss", 0, 0, be(uint32(3), uint32(1), uint32(5), uint32(9))),
+	}
+	//500ms empty edit , then 1s from the first presented sample
+	elstBox := mkFullBox("elst", 0, 0, be(uint32(2), uint32(500), int32(-1), uint32(1<<16), uint32(1000), uint32(9600), uint32(1<<16)))
+	trak := mkTrak(1, "vide", stbl...)
+	trak = mkBox("trak", trak[8:], mkBox("edts", elstBox))
+	data := mkBox("moov", mkMVHD(1000, 1500), trak)
+
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := info.Tracks()[0]
+
+	tests := [...]struct {
+		d      time.Duration
+		number uint32
+		offset int64
+		time   time.Duration
+	}{
+		{0, 1, 8, 500 * time.Millisecond}, //in the empty edit
+		{-time.Second, 1, 8, 500 * time.Millisecond},
+		{time.Second, 5, 408, 900 * time.Millisecond},
+		{1300 * time.Millisecond, 9, 808, 1300 * time.Millisecond},
+		{10 * time.Second, 9, 808, 1300 * time.Millisecond},
+	}
+	for _, test := range tests {
+		got, err := track.Seek(test.d)
+		if err != nil {
+			t.Fatalf("Seek(%v): %v", test.d, err)
+		}
+		if got.Number != test.number || got.Offset != test.offset || got.Time != test.time {
+			t.Errorf("Seek(%v),want sample %d at %v , got %+v", test.d, test.number, test.time, got)
+		}
+	}
+
+	stbl[5] = mkFullBox("stss", 0, 0, be(uint32(0)))
+	info, err = NewReaderAtParser(bytes.NewReader(mkBox("moov", mkMVHD(1000, 1500), mkTrak(1, "vide", stbl...))), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := info.Tracks()[0].Seek(0); err != ErrNoSyncSample {
+		t.Errorf("want %v , got %v", ErrNoSyncSample, err)
+	}
+}
+
+func TestELST(t *testing.T) {
+	box := mkFullBox("elst", 1, 0, be(uint32(2),
+		uint64(1<<33), int64(-1), uint32(1<<16),
+		uint64(3000), int64(1<<40), uint32(0)))
+	b := newELST(mkTestBox(box))
+	if err := b.scan(bytes.NewReader(box)); err != nil {
+		t.Fatal(err)
+	}
+	want := []elstEntry{{1 << 33, -1, 1}, {3000, 1 << 40, 0}}
+	if b.version != 1 || !reflect.DeepEqual(b.entrys, want) {
+		t.Errorf("want %+v , got %+v", want, b.entrys)
+	}
+
+	box = mkFullBox("elst", 2, 0, be(uint32(0)))
+	if err := newELST(mkTestBox(box)).scan(bytes.NewReader(box)); err != ErrUnsupportedVersion {
+		t.Errorf("want %v , got %v", ErrUnsupportedVersion, err)
+	}
+}
@@ -0,0 +1,228 @@
+package mp4parser
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+//Track contains information of a track , collected from trak box
+type Track struct {
+	box    *Box        //trak
+	reader io.ReaderAt //source of sample data
+	size   int64       //size of data in reader
+
+	id          uint32
+	flags       uint32
+	width       float64
+	height      float64
+	handlerType string
+	handlerName string
+	timeScale   uint32
+	rawDuration uint64 //in timeScale
+	language    string
+	codec       string
+	sampleCount uint32
+
+	video *VideoInfo
+	audio *AudioInfo
+
+	samples     []Sample //built by Samples
+	syncSamples []int    //indexes of sync samples in samples , built by Seek
+}
+
+//newTrack return Track collected from trak box b , nil if boxs in b failed to decode in lenient mode ,
+//	return an error , if any
+func newTrack(b *Box) (*Track, error) {
+	tkhdBox, err := b.findBox("tkhd")
+	if err != nil {
+		return nil, err
+	}
+	mdhdBox, err := b.findBox("mdia", "mdhd")
+	if err != nil {
+		return nil, err
+	}
+	hdlrBox, err := b.findBox("mdia", "hdlr")
+	if err != nil {
+		return nil, err
+	}
+
+	tkhdData, ok1 := tkhdBox.payload.(*tkhd)
+	mdhdData, ok2 := mdhdBox.payload.(*mdhd)
+	hdlrData, ok3 := hdlrBox.payload.(*hdlr)
+	if !ok1 || !ok2 || !ok3 { //failed to decode
+		return nil, nil
+	}
+
+	t := &Track{
+		box:         b,
+		id:          tkhdData.trackID,
+		flags:       tkhdData.flags,
+		width:       tkhdData.width,
+		height:      tkhdData.height,
+		handlerType: hdlrData.handlerType,
+		handlerName: hdlrData.name,
+		timeScale:   mdhdData.timeScale,
+		rawDuration: mdhdData.duration,
+		language:    mdhdData.language,
+	}
+
+	//optional boxs
+	if stsdBox, err := b.findBox("mdia", "minf", "stbl", "stsd"); err == nil && len(stsdBox.innerBoxs) > 0 {
+		entry := stsdBox.innerBoxs[0]
+		t.codec = entry.boxType
+
+		switch entryData := entry.payload.(type) {
+		case *visualSampleEntry:
+			entryData.collect()
+			t.video = &entryData.info
+			t.codec = t.video.Codec
+		case *audioSampleEntry:
+			entryData.collect()
+			t.audio = &entryData.info
+			t.codec = t.audio.Codec
+		}
+	}
+	for _, boxType := range [...]string{"stsz", "stz2"} {
+		if stszBox, err := b.findBox("mdia", "minf", "stbl", boxType); err == nil {
+			if stszData, ok := stszBox.payload.(*stsz); ok {
+				t.sampleCount = stszData.sampleCount
+				break
+			}
+		}
+	}
+
+	if t.fragmented() { //samples and duration in moof boxs are added
+		samples, err := t.Samples()
+		if err != nil {
+			return nil, err
+		}
+		t.sampleCount = uint32(len(samples))
+		if n := len(samples); n > 0 {
+			if end := samples[n-1].DTS + int64(samples[n-1].Duration); end > int64(t.rawDuration) {
+				t.rawDuration = uint64(end)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Track) String() string {
+	return fmt.Sprintf(
+		"track %d:%s(%q)\tcodec:%s\tlanguage:%s\tduration:%v\tsamples:%d\twidth:%.2f\theight:%.2f",
+		t.id, t.handlerType, t.handlerName, t.codec, t.language, t.Duration(), t.sampleCount, t.width, t.height)
+}
+
+//ID return track ID
+func (t *Track) ID() uint32 {
+	return t.id
+}
+
+//HandlerType return type of handler , e.g. "vide" , "soun" , "hint"
+func (t *Track) HandlerType() string {
+	return t.handlerType
+}
+
+//HandlerName return name of handler , human-readable
+func (t *Track) HandlerName() string {
+	return t.handlerName
+}
+
+//TimeScale return number of time units per second of media , found in mdhd
+func (t *Track) TimeScale() uint32 {
+	return t.timeScale
+}
+
+//RawDuration return duration of media in units of TimeScale , found in mdhd
+func (t *Track) RawDuration() uint64 {
+	return t.rawDuration
+}
+
+//Duration return duration of media , truncated to nanosecond
+func (t *Track) Duration() time.Duration {
+	return toDuration(t.rawDuration, t.timeScale)
+}
+
+//Language return ISO 639-2/T code of language , "und" if unspecified
+func (t *Track) Language() string {
+	return t.language
+}
+
+//Enabled reports whether the track is enabled
+func (t *Track) Enabled() bool {
+	return t.flags&trackEnabled != 0
+}
+
+//InMovie reports whether the track is used in the presentation
+func (t *Track) InMovie() bool {
+	return t.flags&trackInMovie != 0
+}
+
+//InPreview reports whether the track is used when previewing the presentation
+func (t *Track) InPreview() bool {
+	return t.flags&trackInPreview != 0
+}
+
+//Width return presentation width found in tkhd
+func (t *Track) Width() float64 {
+	return t.width
+}
+
+//Height return presentation height found in tkhd
+func (t *Track) Height() float64 {
+	return t.height
+}
+
+//Codec return four-character code of the first sample entry , e.g. "avc1" , "mp4a" ,
+//	original format if the entry is encrypted
+func (t *Track) Codec() string {
+	return t.codec
+}
+
+//CodecString return RFC 6381 codec string of the first sample entry , its type if not known
+func (t *Track) CodecString() string {
+	if t.video != nil {
+		return t.video.CodecString()
+	}
+	if t.audio != nil {
+		return t.audio.CodecString()
+	}
+	return t.codec
+}
+
+//Video return information of the first visual sample entry , nil if it is not a known one
+func (t *Track) Video() *VideoInfo {
+	return t.video
+}
+
+//Audio return information of the first audio sample entry , nil if it is not a known one
+func (t *Track) Audio() *AudioInfo {
+	return t.audio
+}
+
+//SampleRate return sample rate of audio in Hz , time scale of media if the sample entry is unknown
+func (t *Track) SampleRate() uint32 {
+	if t.audio != nil && t.audio.SampleRate != 0 {
+		return t.audio.SampleRate
+	}
+	return t.timeScale
+}
+
+//ChannelCount return number of audio channels , 0 if the sample entry is unknown
+func (t *Track) ChannelCount() uint16 {
+	if t.audio == nil {
+		return 0
+	}
+	return t.audio.ChannelCount
+}
+
+//SampleCount return number of samples , including those in fragments of fragmented movie
+func (t *Track) SampleCount() uint32 {
+	return t.sampleCount
+}
+
+//Box return the trak box of t
+func (t *Track) Box() *Box {
+	return t.box
+}
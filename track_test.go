@@ -0,0 +1,143 @@
+package mp4parser
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTracks(t *testing.T) {
+	info, err := NewParser(testFile).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		id          uint32
+		handlerType string
+		timeScale   uint32
+		duration    time.Duration
+		language    string
+		enabled     bool
+		inMovie     bool
+		width       float64
+		height      float64
+		codec       string
+		sampleCount uint32
+	}{
+		{1, "vide", 90000, 5533333333, "und", true, false, 560, 320, "avc1", 166},
+		{2, "soun", 48000, 5568 * time.Millisecond, "eng", true, true, 0, 0, "mp4a", 261},
+	}
+
+	tracks := info.Tracks()
+	if len(tracks) != len(tests) {
+		t.Fatalf("want %d tracks , got %d", len(tests), len(tracks))
+	}
+	for i, test := range tests {
+		got := tracks[i]
+		if got.ID() != test.id || got.HandlerType() != test.handlerType || got.TimeScale() != test.timeScale ||
+			got.Duration() != test.duration || got.Language() != test.language ||
+			got.Enabled() != test.enabled || got.InMovie() != test.inMovie ||
+			got.Width() != test.width || got.Height() != test.height ||
+			got.Codec() != test.codec || got.SampleCount() != test.sampleCount {
+			t.Errorf("want %+v\ngot %v", test, got)
+		}
+		if got.Box().Type() != "trak" {
+			t.Errorf("track %d,got box %v", got.ID(), got.Box())
+		}
+	}
+}
+
+func TestTracksMultiAudio(t *testing.T) {
+	hdlr := func(name string) []byte { //QuickTime pascal string
+		return mkFullBox("hdlr", 0, 0, make([]byte, 4), []byte("soun"), make([]byte, 12), []byte{byte(len(name))}, []byte(name))
+	}
+	mdhd := func(timeScale uint32, language uint16) []byte {
+		return mkFullBox("mdhd", 0, 0, be(uint32(0), uint32(0), timeScale, timeScale*3, language, uint16(0)))
+	}
+	trak := func(id uint32, mdhdBox, hdlrBox []byte) []byte {
+		return mkBox("trak", mkTKHD(id, 3000, 0, 0), mkBox("mdia", mdhdBox, hdlrBox))
+	}
+
+	const eng, fra = 0x15c7, 0x1a41
+	data := mkBox("moov",
+		mkMVHD(1000, 3000),
+		trak(1, mdhd(44100, eng), hdlr("English")),
+		trak(2, mdhd(48000, fra), hdlr("Français")),
+		mkBox("trak", mkTKHD(3, 3000, 1280, 720), mkBox("mdia", mkMDHD(25, 75), mkHDLR("vide", "Video"))),
+		mkBox("trak", mkTKHD(4, 3000, 640, 360), mkBox("mdia", mkMDHD(25, 75), mkHDLR("vide", "Angle 2"))))
+
+	info, err := NewReaderAtParser(bytes.NewReader(data), -1).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := [...]struct {
+		id          uint32
+		handlerName string
+		language    string
+		timeScale   uint32
+	}{
+		{1, "English", "eng", 44100},
+		{2, "Français", "fra", 48000},
+		{3, "Video", "und", 25},
+		{4, "Angle 2", "und", 25},
+	}
+	tracks := info.Tracks()
+	if len(tracks) != len(tests) {
+		t.Fatalf("want %d tracks , got %d", len(tests), len(tracks))
+	}
+	for i, test := range tests {
+		got := tracks[i]
+		if got.ID() != test.id || got.HandlerName() != test.handlerName || got.Language() != test.language || got.TimeScale() != test.timeScale {
+			t.Errorf("want %+v\ngot %v", test, got)
+		}
+		if got.Duration() != 3*time.Second {
+			t.Errorf("track %d,want duration 3s , got %v", got.ID(), got.Duration())
+		}
+	}
+
+	//legacy fields come from the first track of each type
+	if info.SamplingRate() != 44100 || info.Width() != 1280 || info.Height() != 720 {
+		t.Errorf("got sampling rate %d , %vx%v", info.SamplingRate(), info.Width(), info.Height())
+	}
+}
+
+func TestTrackFixedPointSize(t *testing.T) {
+	tkhdBox := mkFullBox("tkhd", 0, trackEnabled|trackInMovie,
+		be(uint32(0), uint32(0), uint32(1), uint32(0), uint32(0)), make([]byte, 12), be(uint16(0x0180)), make([]byte, 38),
+		be(uint32(0x00018000), uint32(0x02d00c00))) //volume 1.5 , 1.5x720.046875
+	data := mkBox("moov", mkMVHD(1000, 1000), mkBox("trak", tkhdBox, mkBox("mdia", mkMDHD(25, 0), mkHDLR("vide", ""))))
+
+	p := NewReaderAtParser(bytes.NewReader(data), -1)
+	info, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := info.Tracks()[0]
+	if track.Width() != 1.5 || track.Height() != 720.046875 {
+		t.Errorf("want 1.5x720.046875 , got %vx%v", track.Width(), track.Height())
+	}
+	boxs, _ := p.Query("moov/trak/tkhd")
+	if got := boxs[0].Payload().(*tkhd).volume; got != 1.5 {
+		t.Errorf("volume,want 1.5 , got %v", got)
+	}
+}
+
+func TestDecodeLanguage(t *testing.T) {
+	tests := [...]struct {
+		input uint16
+		want  string
+	}{
+		{0x55c4, "und"},
+		{0x15c7, "eng"},
+		{0x1a41, "fra"},
+		{0, "und"},
+		{0x7fff, "und"},
+	}
+	for _, test := range tests {
+		if got := decodeLanguage(test.input); got != test.want {
+			t.Errorf("input %#x,want %s , got %s", test.input, test.want, got)
+		}
+	}
+}
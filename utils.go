@@ -2,12 +2,28 @@ package mp4parser
 
 import (
 	"fmt"
+	"io"
+	"math"
+	"math/bits"
 	"time"
 )
 
+//readAt reads len(buf) bytes from r at offset off, return an error if fewer bytes were read ,
+//	ErrTruncated if data ends
+func readAt(r io.ReaderAt, buf []byte, off int64) error {
+	n, err := r.ReadAt(buf, off)
+	if n == len(buf) {
+		return nil
+	}
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = ErrTruncated
+	}
+	return err
+}
+
 //getFixTime excepts number of secondselapsed from 1904-Jan-01 00:00:00 UTC ,
 //  return fix time and an error,if any
-func getFixTime(sec uint32) (*time.Time, error) {
+func getFixTime(sec uint64) (*time.Time, error) {
 
 	fixZeroTime, err := time.Parse("2006-Jan-02", "1904-Jan-01")
 	if err != nil {
@@ -18,6 +34,60 @@ func getFixTime(sec uint32) (*time.Time, error) {
 	return &t, nil
 }
 
+//toDuration convert value in timeScale units per second to time.Duration ,
+//	the result is truncated to nanosecond and clamped to the max time.Duration
+func toDuration(value uint64, timeScale uint32) time.Duration {
+	if timeScale == 0 {
+		return 0
+	}
+
+	sec, rem := value/uint64(timeScale), value%uint64(timeScale)
+	if sec >= uint64(math.MaxInt64/time.Second) {
+		return math.MaxInt64
+	}
+
+	return time.Duration(sec)*time.Second + time.Duration(rem*uint64(time.Second)/uint64(timeScale))
+}
+
+//scaleTime convert signed value in from units per second to units of to ,
+//	the result is truncated toward zero and clamped to the range of int64
+func scaleTime(value int64, to, from uint32) int64 {
+	if from == 0 {
+		return 0
+	}
+
+	abs := uint64(value)
+	if value < 0 {
+		abs = uint64(-value)
+	}
+	hi, lo := bits.Mul64(abs, uint64(to))
+	if hi >= uint64(from) {
+		if value < 0 {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	quo, _ := bits.Div64(hi, lo, uint64(from))
+	if quo > math.MaxInt64 {
+		quo = math.MaxInt64
+	}
+
+	if value < 0 {
+		return -int64(quo)
+	}
+	return int64(quo)
+}
+
+//fixed32ToF convert [16.16] fixed point number to float
+func fixed32ToF(n uint32) float64 {
+	return float64(n) / (1 << 16)
+}
+
+//fixed16ToF convert signed [8.8] fixed point number to float
+func fixed16ToF(n uint16) float64 {
+	return float64(int16(n)) / (1 << 8)
+}
+
 //dottedNotationToF convert dotted notation ,[8.8]/[16.16] , to float
 //	len(n)<=4 and should not be odd length
 //  e.g.
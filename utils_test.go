@@ -83,3 +83,61 @@ func TestGetFixTime(t *testing.T) {
 		t.Errorf("want: %v \t got: %v", want, got)
 	}
 }
+
+func TestToDuration(t *testing.T) {
+	tests := [...]struct {
+		value     uint64
+		timeScale uint32
+		want      time.Duration
+	}{
+		{501120, 90000, 5568 * time.Millisecond},
+		{1, 3, 333333333},
+		{0, 1000, 0},
+		{1000, 0, 0},
+		{36000000000, 1000000, 10 * time.Hour},
+		{math.MaxUint64, 1, math.MaxInt64},
+	}
+
+	for _, test := range tests {
+		if got := toDuration(test.value, test.timeScale); got != test.want {
+			t.Errorf("toDuration(%d,%d),want %v , got %v", test.value, test.timeScale, test.want, got)
+		}
+	}
+}
+
+func TestScaleTime(t *testing.T) {
+	tests := [...]struct {
+		value    int64
+		to, from uint32
+		want     int64
+	}{
+		{48000, 1000, 48000, 1000},
+		{-1024, uint32(time.Second), 48000, -21333333},
+		{math.MaxInt64, 90000, 1000, math.MaxInt64},
+		{math.MinInt64 + 1, 90000, 1000, math.MinInt64},
+		{1, 1, 0, 0},
+	}
+	for _, test := range tests {
+		if got := scaleTime(test.value, test.to, test.from); got != test.want {
+			t.Errorf("scaleTime(%d, %d, %d),want %d , got %d", test.value, test.to, test.from, test.want, got)
+		}
+	}
+}
+
+func TestFixedToF(t *testing.T) {
+	if got := fixed32ToF(0x00018000); got != 1.5 {
+		t.Errorf("fixed32ToF(0x00018000),want 1.5 , got %v", got)
+	}
+	for _, test := range [...]struct {
+		input uint16
+		want  float64
+	}{
+		{0x0100, 1},
+		{0x0080, 0.5},
+		{0xff00, -1},
+	} {
+		if got := fixed16ToF(test.input); got != test.want {
+			t.Errorf("fixed16ToF(%#04x),want %v , got %v", test.input, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package mp4parser
+
+import (
+	"encoding/binary"
+)
+
+//VPConfig VP codec configuration box , vpcC , in vp08 and vp09 sample entries
+type VPConfig struct {
+	Version           uint8
+	Profile           uint8
+	Level             uint8 //10 times of level number , e.g. 31 for level 3.1
+	BitDepth          uint8
+	ChromaSubsampling uint8 //0 for 4:2:0 vertical , 1 for 4:2:0 colocated , 2 for 4:2:2 , 3 for 4:4:4
+
+	VUI //colour description , SAR is not used
+
+	CodecInitializationData []byte
+}
+
+//parseVPConfig parses data of vpcC box , a full box of VPCodecConfigurationRecord
+/**
+*version						1
+*flags							3
+*profile						1
+*level							1
+*bitDepth						4 bits
+*chromaSubsampling				3 bits
+*videoFullRangeFlag				1 bit
+*colourPrimaries				1
+*transferCharacteristics		1
+*matrixCoefficients				1
+*codecIntializationDataSize		2
+*codecIntializationData			codecIntializationDataSize
+*								//version 0 instead
+*bitDepth						4 bits
+*colorSpace						4 bits
+*chromaSubsampling				4 bits
+*transferFunction				4 bits
+*videoFullRangeFlag				1 bit
+*_reserved						7 bits
+*codecIntializationDataSize		2
+ */
+func parseVPConfig(data []byte) (interface{}, error) {
+	if len(data) < 11 {
+		return nil, ErrTruncated
+	}
+	c := &VPConfig{
+		Version: data[0],
+		Profile: data[4],
+		Level:   data[5],
+		VUI:     defaultVUI(),
+	}
+
+	var size int
+	switch c.Version {
+	case 1:
+		if len(data) < 12 {
+			return nil, ErrTruncated
+		}
+		c.BitDepth = data[6] >> 4
+		c.ChromaSubsampling = data[6] >> 1 & 7
+		c.VideoFullRange = data[6]&1 != 0
+		c.ColourPrimaries = data[7]
+		c.TransferCharacteristics = data[8]
+		c.MatrixCoefficients = data[9]
+		size = int(binary.BigEndian.Uint16(data[10:12]))
+		data = data[12:]
+	case 0:
+		c.BitDepth = data[6] >> 4
+		c.ChromaSubsampling = data[7] >> 4
+		c.VideoFullRange = data[8]&0x80 != 0
+		size = int(binary.BigEndian.Uint16(data[9:11]))
+		data = data[11:]
+	default:
+		return nil, ErrUnsupportedVersion
+	}
+
+	if len(data) < size {
+		return nil, ErrTruncated
+	}
+	if size > 0 {
+		c.CodecInitializationData = append([]byte(nil), data[:size]...)
+	}
+
+	return c, nil
+}
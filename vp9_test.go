@@ -0,0 +1,49 @@
+package mp4parser
+
+import (
+	"testing"
+)
+
+func TestParseVPConfig(t *testing.T) {
+	tests := [...]struct {
+		name string
+		data []byte
+		want VPConfig
+	}{
+		{"version 1", []byte{1, 0, 0, 0, 2, 31, 0xa3, 9, 16, 9, 0, 2, 0xab, 0xcd},
+			VPConfig{Version: 1, Profile: 2, Level: 31, BitDepth: 10, ChromaSubsampling: 1,
+				VUI:                     VUI{VideoFullRange: true, ColourPrimaries: 9, TransferCharacteristics: 16, MatrixCoefficients: 9},
+				CodecInitializationData: []byte{0xab, 0xcd}}},
+		{"version 0", []byte{0, 0, 0, 0, 0, 10, 0x80, 0x10, 0x80, 0, 0},
+			VPConfig{Profile: 0, Level: 10, BitDepth: 8, ChromaSubsampling: 1,
+				VUI: VUI{VideoFullRange: true, ColourPrimaries: 2, TransferCharacteristics: 2, MatrixCoefficients: 2}}},
+	}
+	for _, test := range tests {
+		got, err := parseVPConfig(test.data)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		c := got.(*VPConfig)
+		if c.Version != test.want.Version || c.Profile != test.want.Profile || c.Level != test.want.Level ||
+			c.BitDepth != test.want.BitDepth || c.ChromaSubsampling != test.want.ChromaSubsampling ||
+			c.VUI != test.want.VUI || string(c.CodecInitializationData) != string(test.want.CodecInitializationData) {
+			t.Errorf("%s: want %+v , got %+v", test.name, test.want, c)
+		}
+	}
+
+	info := &VideoInfo{Codec: "vp09", VP: &VPConfig{Profile: 2, Level: 31, BitDepth: 10, VUI: VUI{TransferCharacteristics: transferHLG}}}
+	if got := info.CodecString(); got != "vp09.02.31.10" || !info.HDR() || info.BitDepth() != 10 {
+		t.Errorf("want vp09.02.31.10 of 10 bits HDR , got %s , %d , %v", got, info.BitDepth(), info.HDR())
+	}
+
+	for _, data := range [...][]byte{
+		{1, 0, 0, 0, 2, 31, 0xa3, 9, 16, 9},
+		{1, 0, 0, 0, 2, 31, 0xa3, 9, 16, 9, 0, 2, 0xab},
+		{2, 0, 0, 0, 2, 31, 0xa3, 9, 16, 9, 0, 0},
+	} {
+		if _, err := parseVPConfig(data); err == nil {
+			t.Errorf("parseVPConfig(%x),want error", data)
+		}
+	}
+}